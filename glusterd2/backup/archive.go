@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatVersion is the version of the archive format. It is increased
+	// when the format changes incompatibly.
+	FormatVersion = 1
+
+	manifestFile   = "manifest.json"
+	storeFile      = "store.json"
+	volfilesPrefix = "volfiles/"
+)
+
+// Manifest describes a backup archive
+type Manifest struct {
+	FormatVersion int `json:"format-version"`
+	// Revision is the store revision at which the store was backed up
+	Revision        int64     `json:"revision"`
+	Created         time.Time `json:"created"`
+	Node            string    `json:"node"`
+	GlusterdVersion string    `json:"glusterd-version"`
+}
+
+// KeyValue is a key of the store and its value
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// archive is the content of a backup archive
+type archive struct {
+	Manifest Manifest
+	KVs      []KeyValue
+	// Volfiles maps the paths of the volfiles, relative to the volumes
+	// directory, to their content
+	Volfiles map[string][]byte
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// write writes the archive to w as a gzipped tarball. The manifest is the
+// first file of the tarball.
+func (a *archive) write(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, manifestFile, manifest); err != nil {
+		return err
+	}
+
+	kvs, err := json.Marshal(a.KVs)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, storeFile, kvs); err != nil {
+		return err
+	}
+
+	for name, data := range a.Volfiles {
+		if err := writeTarFile(tw, volfilesPrefix+name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// readArchive reads an archive written by archive.write()
+func readArchive(r io.Reader) (*archive, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	a := &archive{Volfiles: make(map[string][]byte)}
+	var manifestFound, storeFound bool
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case hdr.Name == manifestFile:
+			if err := json.Unmarshal(data, &a.Manifest); err != nil {
+				return nil, err
+			}
+			if a.Manifest.FormatVersion != FormatVersion {
+				return nil, fmt.Errorf("unsupported backup format version %d", a.Manifest.FormatVersion)
+			}
+			manifestFound = true
+		case !manifestFound:
+			return nil, fmt.Errorf("backup manifest not found")
+		case hdr.Name == storeFile:
+			if err := json.Unmarshal(data, &a.KVs); err != nil {
+				return nil, err
+			}
+			storeFound = true
+		case strings.HasPrefix(hdr.Name, volfilesPrefix):
+			name := path.Clean(strings.TrimPrefix(hdr.Name, volfilesPrefix))
+			if path.IsAbs(name) || strings.HasPrefix(name, "..") {
+				return nil, fmt.Errorf("invalid volfile path in backup: %s", hdr.Name)
+			}
+			a.Volfiles[name] = data
+		}
+	}
+
+	if !storeFound {
+		return nil, fmt.Errorf("store data not found in backup")
+	}
+	return a, nil
+}
@@ -0,0 +1,205 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+	config "github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestArchive validates that archives are read back as written
+func TestArchive(t *testing.T) {
+	a := &archive{
+		Manifest: Manifest{
+			FormatVersion: FormatVersion,
+			Revision:      42,
+			Created:       time.Now().UTC().Truncate(time.Second),
+			Node:          "node1",
+		},
+		KVs: []KeyValue{
+			{Key: "gluster/volumes/vol1", Value: []byte(`{"Name":"vol1"}`)},
+			{Key: "gluster/peers/node1", Value: []byte(`{}`)},
+		},
+		Volfiles: map[string][]byte{
+			"vol1/vol1.node1.bricks-b1.vol": []byte("volume vol1-posix\nend-volume\n"),
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, a.write(&buf))
+	b, err := readArchive(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, a, b)
+
+	// Archives of an unknown format version are rejected
+	a.Manifest.FormatVersion = FormatVersion + 1
+	buf.Reset()
+	assert.Nil(t, a.write(&buf))
+	_, err = readArchive(&buf)
+	assert.NotNil(t, err)
+}
+
+// TestExcluded validates the keys which are not backed up
+func TestExcluded(t *testing.T) {
+	assert.True(t, excluded("gluster/txnjournal/1234"))
+	assert.False(t, excluded("gluster/volumes/vol1"))
+}
+
+// initTestStore starts an embedded store for the tests which need one
+func initTestStore(t *testing.T) func() {
+	if testing.Short() {
+		t.Skip("skipping embedded store test in short mode")
+	}
+
+	gdctx.MyUUID = uuid.NewRandom()
+	dir, err := ioutil.TempDir("", "gd2backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	destroy, err := store.InitTestStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return func() {
+		destroy()
+		os.RemoveAll(dir)
+	}
+}
+
+// storeKVs returns the keys of the store which aren't attached to leases
+func storeKVs(t *testing.T) map[string]string {
+	resp, err := store.Store.Get(context.Background(), store.GlusterPrefix, clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvs := make(map[string]string)
+	for _, kv := range resp.Kvs {
+		if kv.Lease == 0 {
+			kvs[string(kv.Key)] = string(kv.Value)
+		}
+	}
+	return kvs
+}
+
+// TestBackupRestore validates that a backup restored into a fresh store
+// restores the keys and the volfiles, and that interrupted restores are
+// resumed
+func TestBackupRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gd2backup-vols")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.Set("localstatedir", dir)
+	defer config.Set("localstatedir", "")
+
+	volfile := path.Join(volumesDir(), "vol1", "vol1.vol")
+	assert.Nil(t, os.MkdirAll(path.Dir(volfile), 0755))
+	assert.Nil(t, ioutil.WriteFile(volfile, []byte("volume vol1-posix\nend-volume\n"), 0644))
+
+	// More keys than restored by a single store transaction
+	destroy := initTestStore(t)
+	for i := 0; i < 2*maxTxnOps; i++ {
+		_, err := store.Store.Put(context.Background(), fmt.Sprintf("%svolumes/vol%d", store.GlusterPrefix, i), "{}")
+		assert.Nil(t, err)
+	}
+	_, err = store.Store.Put(context.Background(), store.GlusterPrefix+"txnjournal/1234", "{}")
+	assert.Nil(t, err)
+	// The secrets of webhooks aren't backed up
+	wh := &webhook.Webhook{ID: uuid.NewRandom(), URL: "http://localhost/events", Secret: "secret"}
+	assert.Nil(t, webhook.Add(wh))
+	backedUp := storeKVs(t)
+	delete(backedUp, store.GlusterPrefix+"txnjournal/1234")
+	wh.Secret = ""
+	b, err := json.Marshal(wh)
+	assert.Nil(t, err)
+	backedUp[webhook.StorePrefix+wh.ID.String()] = string(b)
+
+	var buf bytes.Buffer
+	assert.Nil(t, Backup(context.Background(), &buf))
+	destroy()
+	saved := buf.Bytes()
+
+	assert.Nil(t, os.Remove(volfile))
+	defer initTestStore(t)()
+
+	assert.Nil(t, Restore(bytes.NewReader(saved)))
+	assert.Equal(t, backedUp, storeKVs(t))
+	data, err := ioutil.ReadFile(volfile)
+	assert.Nil(t, err)
+	assert.Equal(t, "volume vol1-posix\nend-volume\n", string(data))
+	assert.Nil(t, CheckRestore())
+
+	// Restoring into a store which isn't fresh fails
+	assert.Equal(t, ErrStoreNotEmpty, Restore(bytes.NewReader(saved)))
+}
+
+// TestRestoreInterrupted validates that an interrupted restore is detected,
+// and only completed by restoring the same backup
+func TestRestoreInterrupted(t *testing.T) {
+	defer initTestStore(t)()
+
+	a := &archive{
+		Manifest: Manifest{FormatVersion: FormatVersion, Revision: 42, Node: "node1"},
+		KVs: []KeyValue{
+			{Key: store.GlusterPrefix + "volumes/vol1", Value: []byte("{}")},
+			{Key: store.GlusterPrefix + "volumes/vol2", Value: []byte("{}")},
+		},
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, a.write(&buf))
+	saved := buf.Bytes()
+
+	// The restore was interrupted after the first key
+	_, err := store.Store.Put(context.Background(), restoreMarkerKey, a.restoreMarker())
+	assert.Nil(t, err)
+	_, err = store.Store.Put(context.Background(), store.GlusterPrefix+"volumes/vol1", "{}")
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRestoreIncomplete, CheckRestore())
+
+	// Another backup isn't restored over it
+	other := *a
+	other.Manifest.Revision = 43
+	buf.Reset()
+	assert.Nil(t, other.write(&buf))
+	assert.Equal(t, ErrRestoreIncomplete, Restore(&buf))
+
+	assert.Nil(t, Restore(bytes.NewReader(saved)))
+	assert.Nil(t, CheckRestore())
+	kvs := storeKVs(t)
+	assert.Contains(t, kvs, store.GlusterPrefix+"volumes/vol2")
+	assert.NotContains(t, kvs, restoreMarkerKey)
+}
+
+// TestRestoreInvalidKeys validates that backups with keys outside of the
+// cluster state are rejected
+func TestRestoreInvalidKeys(t *testing.T) {
+	defer initTestStore(t)()
+
+	a := &archive{
+		Manifest: Manifest{FormatVersion: FormatVersion, Node: "node1"},
+		KVs:      []KeyValue{{Key: "other/key", Value: []byte("{}")}},
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, a.write(&buf))
+	assert.NotNil(t, Restore(&buf))
+
+	resp, err := store.Store.Get(context.Background(), "other/key")
+	assert.Nil(t, err)
+	assert.Empty(t, resp.Kvs)
+}
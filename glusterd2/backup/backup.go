@@ -0,0 +1,137 @@
+// Package backup implements backing up the cluster state kept in the store,
+// and restoring it into a fresh store, to recover from the loss of the store
+// quorum. The secrets of webhooks are left out of backups.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
+	"github.com/gluster/glusterd2/version"
+
+	"github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+// excludedPrefixes are the prefixes of the keys which are not backed up, the
+// contexts and journals of transactions in progress, which can't be resumed
+// in a restored cluster. Keys attached to leases, like locks and liveness
+// keys, aren't backed up either.
+var excludedPrefixes = []string{
+	store.GlusterPrefix + "transaction/",
+	store.GlusterPrefix + "txnjournal/",
+}
+
+// redactWebhook removes the secret of a backed up webhook. Archives aren't
+// encrypted, so the secrets of restored webhooks have to be set again by
+// adding them again.
+func redactWebhook(value []byte) ([]byte, error) {
+	var w webhook.Webhook
+	if err := json.Unmarshal(value, &w); err != nil {
+		return nil, err
+	}
+	w.Secret = ""
+	return json.Marshal(&w)
+}
+
+func excluded(key string) bool {
+	for _, p := range excludedPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// volumesDir returns the directory the volfiles generated on this node are
+// written to
+func volumesDir() string {
+	return path.Join(config.GetString("localstatedir"), "vols")
+}
+
+// Backup writes an archive of the store and of the volfiles generated on this
+// node to w. A shared cluster lock is held while the store is read, so that
+// no transaction is in progress, and all the keys are read at a single store
+// revision.
+func Backup(ctx context.Context, w io.Writer) error {
+	unlock, err := transaction.Lock(ctx, transaction.ClusterLockKey, transaction.LockShared)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	resp, err := store.Store.Get(ctx, store.GlusterPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	a := &archive{
+		Manifest: Manifest{
+			FormatVersion:   FormatVersion,
+			Revision:        resp.Header.Revision,
+			Created:         time.Now().UTC(),
+			Node:            gdctx.MyUUID.String(),
+			GlusterdVersion: version.GlusterdVersion,
+		},
+		Volfiles: make(map[string][]byte),
+	}
+	for _, kv := range resp.Kvs {
+		if kv.Lease != 0 || excluded(string(kv.Key)) {
+			continue
+		}
+		value := kv.Value
+		if strings.HasPrefix(string(kv.Key), webhook.StorePrefix) {
+			if value, err = redactWebhook(value); err != nil {
+				return err
+			}
+		}
+		a.KVs = append(a.KVs, KeyValue{Key: string(kv.Key), Value: value})
+	}
+
+	dir := volumesDir()
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".vol") {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		a.Volfiles[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"revision": a.Manifest.Revision,
+		"keys":     len(a.KVs),
+		"volfiles": len(a.Volfiles),
+	}).Info("backed up store")
+
+	return a.write(w)
+}
@@ -0,0 +1,171 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxTxnOps is the number of operations per store transaction restoring
+// keys, which is below the default limit of operations in a transaction of
+// the store. One of them is used for the restore marker.
+const maxTxnOps = 100
+
+// restoreMarkerKey is the key which exists while a backup is being restored.
+// Its value identifies the backup.
+const restoreMarkerKey = store.GlusterPrefix + "restore-in-progress"
+
+var (
+	// ErrStoreNotEmpty is returned when restoring a backup into a store
+	// which already has cluster state
+	ErrStoreNotEmpty = errors.New("store is not empty, backups can only be restored into a fresh store")
+
+	// ErrRestoreIncomplete is returned when the restore of another backup
+	// was interrupted, or when the store is used before an interrupted
+	// restore is completed
+	ErrRestoreIncomplete = errors.New("the restore of a backup was interrupted, restore the same backup again to complete it")
+)
+
+// RestoreFile restores the backup archive at the given path
+func RestoreFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Restore(f)
+}
+
+// CheckRestore returns ErrRestoreIncomplete if the restore of a backup into
+// the store was interrupted
+func CheckRestore() error {
+	resp, err := store.Store.Get(context.TODO(), restoreMarkerKey, clientv3.WithKeysOnly())
+	if err != nil {
+		return err
+	}
+	if resp.Count != 0 {
+		return ErrRestoreIncomplete
+	}
+	return nil
+}
+
+// restoreMarker returns the value of the restore marker for the archive
+func (a *archive) restoreMarker() string {
+	return fmt.Sprintf("%s@%d", a.Manifest.Node, a.Manifest.Revision)
+}
+
+// Restore restores a backup archive written by Backup(). The keys are
+// restored into the store, which must be a fresh store, and the volfiles are
+// written to the volumes directory of this node, if they don't exist there.
+// The other peers of the backed up cluster need to join the restored store.
+//
+// The keys are restored in batches, guarded by a restore marker key which is
+// set with the first batch and deleted with the last one. An interrupted
+// restore is resumed by restoring the same archive again.
+func Restore(r io.Reader) error {
+	a, err := readArchive(r)
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range a.KVs {
+		if !strings.HasPrefix(kv.Key, store.GlusterPrefix) || kv.Key == restoreMarkerKey {
+			return fmt.Errorf("invalid key in backup: %s", kv.Key)
+		}
+	}
+
+	marker := a.restoreMarker()
+	resp, err := store.Store.Get(context.TODO(), store.GlusterPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	var resuming bool
+	for _, kv := range resp.Kvs {
+		if string(kv.Key) == restoreMarkerKey {
+			if string(kv.Value) != marker {
+				return ErrRestoreIncomplete
+			}
+			resuming = true
+		}
+	}
+	if !resuming {
+		for _, kv := range resp.Kvs {
+			if kv.Lease == 0 {
+				return ErrStoreNotEmpty
+			}
+		}
+	} else {
+		log.WithField("backup", marker).Info("resuming interrupted restore")
+	}
+
+	batch := maxTxnOps - 1
+	for start := 0; start == 0 || start < len(a.KVs); start += batch {
+		end := start + batch
+		if end > len(a.KVs) {
+			end = len(a.KVs)
+		}
+		first := start == 0
+		last := end == len(a.KVs)
+
+		// The first batch of a new restore sets the marker if it doesn't
+		// exist, and the other batches only apply while the marker is
+		// the one of this restore
+		cmp := clientv3.Compare(clientv3.Value(restoreMarkerKey), "=", marker)
+		ops := make([]clientv3.Op, 0, end-start+1)
+		if first && !resuming {
+			cmp = clientv3.Compare(clientv3.CreateRevision(restoreMarkerKey), "=", 0)
+			if !last {
+				ops = append(ops, clientv3.OpPut(restoreMarkerKey, marker))
+			}
+		}
+		for _, kv := range a.KVs[start:end] {
+			ops = append(ops, clientv3.OpPut(kv.Key, string(kv.Value)))
+		}
+		if last {
+			ops = append(ops, clientv3.OpDelete(restoreMarkerKey))
+		}
+
+		txnResp, err := store.Store.Txn(context.TODO()).If(cmp).Then(ops...).Commit()
+		if err != nil {
+			return err
+		}
+		if !txnResp.Succeeded {
+			return ErrRestoreIncomplete
+		}
+	}
+
+	dir := volumesDir()
+	for name, data := range a.Volfiles {
+		p := path.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(path.Dir(p), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"revision": a.Manifest.Revision,
+		"created":  a.Manifest.Created,
+		"node":     a.Manifest.Node,
+		"keys":     len(a.KVs),
+		"volfiles": len(a.Volfiles),
+	}).Info("restored store from backup")
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package cluster implements the cluster-wide state of GlusterD
+package cluster
+
+// The cluster op-version is the op-version all the peers of the cluster
+// operate at. New behaviour introduced in an op-version, like new StepFuncs or
+// volume options, must only be used once the cluster op-version is at least
+// that op-version, which ensures that all the peers support it.
+//
+// Every peer publishes the range of op-versions it supports in its peer
+// details. The cluster op-version is set when the cluster is created to the
+// highest op-version supported by the peers, and can later be raised, for
+// example after all the peers have been upgraded, but never lowered.
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/errors"
+	"github.com/gluster/glusterd2/version"
+
+	"github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+)
+
+const opVersionKey = store.GlusterPrefix + "cluster/opversion"
+
+// getOpVersion returns the cluster op-version and the store revision at which
+// it was last modified, which is 0 if it has never been set
+func getOpVersion() (int, int64, error) {
+	resp, err := store.Store.Get(context.TODO(), opVersionKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	if resp.Count == 0 {
+		return version.MinOpVersion, 0, nil
+	}
+
+	v, err := strconv.Atoi(string(resp.Kvs[0].Value))
+	return v, resp.Kvs[0].ModRevision, err
+}
+
+// putOpVersion sets the cluster op-version, if it hasn't been modified since
+// the given store revision
+func putOpVersion(opVersion int, rev int64) error {
+	resp, err := store.Store.Txn(context.TODO()).
+		If(clientv3.Compare(clientv3.ModRevision(opVersionKey), "=", rev)).
+		Then(clientv3.OpPut(opVersionKey, strconv.Itoa(opVersion))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errors.ErrOpVersionChanged
+	}
+	return nil
+}
+
+// OpVersion returns the cluster op-version
+func OpVersion() (int, error) {
+	v, _, err := getOpVersion()
+	return v, err
+}
+
+// SupportedOpVersions returns the lowest and the highest op-versions supported
+// by all the peers
+func SupportedOpVersions() (int, int, error) {
+	peers, err := peer.GetPeers()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	min, max := version.MinOpVersion, version.MaxOpVersion
+	for _, p := range peers {
+		if p == nil {
+			continue
+		}
+		pmin, pmax := p.OpVersions()
+		if pmin > min {
+			min = pmin
+		}
+		if pmax < max {
+			max = pmax
+		}
+	}
+	return min, max, nil
+}
+
+// InitOpVersion sets the cluster op-version to the highest op-version
+// supported by all the peers, if it hasn't been set yet. It is called at
+// startup, after the peer has published its details.
+func InitOpVersion() error {
+	_, rev, err := getOpVersion()
+	if err != nil || rev != 0 {
+		return err
+	}
+
+	_, max, err := SupportedOpVersions()
+	if err != nil {
+		return err
+	}
+
+	err = putOpVersion(max, 0)
+	if err == errors.ErrOpVersionChanged {
+		// Another peer initialized it first
+		return nil
+	}
+	if err == nil {
+		log.WithField("op-version", max).Info("initialized cluster op-version")
+	}
+	return err
+}
+
+// checkOpVersion checks that the cluster op-version can be set to the given
+// op-version, and returns the cluster op-version and the store revision at
+// which it was last modified
+func checkOpVersion(opVersion int) (int, int64, error) {
+	current, rev, err := getOpVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+	if opVersion < current {
+		return 0, 0, errors.ErrOpVersionTooLow
+	}
+
+	min, max, err := SupportedOpVersions()
+	if err != nil {
+		return 0, 0, err
+	}
+	if opVersion < min || opVersion > max {
+		return 0, 0, errors.ErrOpVersionUnsupported
+	}
+	return current, rev, nil
+}
+
+// CheckOpVersion checks that the cluster op-version can be set to the given
+// op-version, without setting it
+func CheckOpVersion(opVersion int) error {
+	_, _, err := checkOpVersion(opVersion)
+	return err
+}
+
+// SetOpVersion raises the cluster op-version to the given op-version, which
+// must be supported by all the peers. The cluster op-version cannot be
+// lowered.
+func SetOpVersion(opVersion int) error {
+	current, rev, err := checkOpVersion(opVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := putOpVersion(opVersion, rev); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"old": current,
+		"new": opVersion,
+	}).Info("cluster op-version changed")
+	return nil
+}
+
+// CheckPeer checks that the given peer supports the cluster op-version
+func CheckPeer(p *peer.Peer) error {
+	current, err := OpVersion()
+	if err != nil {
+		return err
+	}
+
+	if min, max := p.OpVersions(); current < min || current > max {
+		return errors.ErrOpVersionUnsupported
+	}
+	return nil
+}
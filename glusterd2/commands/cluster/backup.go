@@ -0,0 +1,42 @@
+package clustercommands
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/backup"
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
+	"github.com/gluster/glusterd2/pkg/api"
+)
+
+// backupHandler responds with a backup archive of the store, which can be
+// restored with `glusterd2 --restore <file>`
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	// The archive is built before responding, so that failures can be
+	// reported with the right status code
+	var buf bytes.Buffer
+	if err := backup.Backup(ctx, &buf); err != nil {
+		logger.WithError(err).Error("failed to back up store")
+		status := http.StatusInternalServerError
+		if err == transaction.ErrLockTimeout {
+			status = http.StatusConflict
+		}
+		restutils.SendHTTPError(ctx, w, status, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	filename := fmt.Sprintf("glusterd2-backup-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.WithError(err).Error("failed to send backup")
+	}
+}
@@ -0,0 +1,43 @@
+// Package clustercommands implements the commands to view and change the
+// cluster-wide settings, and to back up the cluster state
+package clustercommands
+
+import (
+	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
+)
+
+// Command is a holding struct used to implement the GlusterD Command interface
+type Command struct {
+}
+
+// Routes returns command routes. Required for the Command interface.
+func (c *Command) Routes() route.Routes {
+	return route.Routes{
+		route.Route{
+			Name:        "GetClusterOpVersion",
+			Method:      "GET",
+			Pattern:     "/cluster/op-version",
+			Version:     1,
+			HandlerFunc: getOpVersionHandler,
+		},
+		route.Route{
+			Name:        "SetClusterOpVersion",
+			Method:      "POST",
+			Pattern:     "/cluster/op-version",
+			Version:     1,
+			HandlerFunc: setOpVersionHandler,
+		},
+		route.Route{
+			Name:        "BackupCluster",
+			Method:      "POST",
+			Pattern:     "/cluster/backup",
+			Version:     1,
+			HandlerFunc: backupHandler,
+		},
+	}
+}
+
+// RegisterStepFuncs implements a required function for the Command interface
+func (c *Command) RegisterStepFuncs() {
+	return
+}
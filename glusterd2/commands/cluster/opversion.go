@@ -0,0 +1,86 @@
+package clustercommands
+
+import (
+	"net/http"
+
+	"github.com/gluster/glusterd2/glusterd2/cluster"
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/errors"
+)
+
+func opVersionResp() (*api.ClusterOpVersionResp, error) {
+	current, err := cluster.OpVersion()
+	if err != nil {
+		return nil, err
+	}
+	min, max, err := cluster.SupportedOpVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ClusterOpVersionResp{
+		OpVersion:    current,
+		MinOpVersion: min,
+		MaxOpVersion: max,
+	}, nil
+}
+
+func getOpVersionHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	resp, err := opVersionResp()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
+
+func setOpVersionHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	var req api.ClusterOpVersionReq
+	if err := restutils.UnmarshalRequest(r, &req); err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusUnprocessableEntity, errors.ErrJSONParsingFailed.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	dryRun := restutils.IsDryRunRequest(r)
+	var err error
+	if dryRun {
+		err = cluster.CheckOpVersion(req.OpVersion)
+	} else {
+		err = cluster.SetOpVersion(req.OpVersion)
+	}
+	switch err {
+	case nil:
+	case errors.ErrOpVersionTooLow, errors.ErrOpVersionUnsupported:
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	case errors.ErrOpVersionChanged:
+		restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
+		return
+	default:
+		logger.WithError(err).Error("failed to set cluster op-version")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	if dryRun {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
+	resp, err := opVersionResp()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
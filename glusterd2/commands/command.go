@@ -2,7 +2,12 @@
 package commands
 
 import (
+	"github.com/gluster/glusterd2/glusterd2/commands/cluster"
+	"github.com/gluster/glusterd2/glusterd2/commands/events"
+	"github.com/gluster/glusterd2/glusterd2/commands/jobs"
 	"github.com/gluster/glusterd2/glusterd2/commands/peers"
+	"github.com/gluster/glusterd2/glusterd2/commands/store"
+	"github.com/gluster/glusterd2/glusterd2/commands/transactions"
 	"github.com/gluster/glusterd2/glusterd2/commands/version"
 	"github.com/gluster/glusterd2/glusterd2/commands/volumes"
 	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
@@ -21,4 +26,9 @@ var Commands = []Command{
 	&versioncommands.Command{},
 	&volumecommands.Command{},
 	&peercommands.Command{},
+	&jobcommands.Command{},
+	&transactioncommands.Command{},
+	&clustercommands.Command{},
+	&eventcommands.Command{},
+	&storecommands.Command{},
 }
@@ -0,0 +1,50 @@
+// Package eventcommands implements the event stream and webhook ReST end
+// points
+package eventcommands
+
+import (
+	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
+)
+
+// Command is a holding struct used to implement the GlusterD Command interface
+type Command struct {
+}
+
+// Routes returns command routes. Required for the Command interface.
+func (c *Command) Routes() route.Routes {
+	return route.Routes{
+		route.Route{
+			Name:        "GetEvents",
+			Method:      "GET",
+			Pattern:     "/events",
+			Version:     1,
+			HandlerFunc: getEventsHandler,
+		},
+		route.Route{
+			Name:        "AddWebhook",
+			Method:      "POST",
+			Pattern:     "/events/webhooks",
+			Version:     1,
+			HandlerFunc: addWebhookHandler,
+		},
+		route.Route{
+			Name:        "GetWebhooks",
+			Method:      "GET",
+			Pattern:     "/events/webhooks",
+			Version:     1,
+			HandlerFunc: getWebhooksHandler,
+		},
+		route.Route{
+			Name:        "DeleteWebhook",
+			Method:      "DELETE",
+			Pattern:     "/events/webhooks/{webhookid}",
+			Version:     1,
+			HandlerFunc: deleteWebhookHandler,
+		},
+	}
+}
+
+// RegisterStepFuncs implements a required function for the Command interface
+func (c *Command) RegisterStepFuncs() {
+	return
+}
@@ -0,0 +1,106 @@
+package eventcommands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/events"
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/pkg/api"
+)
+
+// keepaliveInterval is the interval at which comments are sent on idle event
+// streams, so that proxies and clients don't time the connection out
+const keepaliveInterval = 30 * time.Second
+
+// afterRevision returns the store revision after which events are requested.
+// It is taken from the after-revision query parameter, or from the
+// Last-Event-ID header sent by clients reconnecting to a server-sent events
+// stream.
+func afterRevision(r *http.Request) (int64, error) {
+	v := r.URL.Query().Get("after-revision")
+	if v == "" {
+		v = r.Header.Get("Last-Event-ID")
+	}
+	if v == "" {
+		return 0, nil
+	}
+
+	rev, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || rev < 0 {
+		return 0, fmt.Errorf("invalid revision: %s", v)
+	}
+	return rev, nil
+}
+
+// getEventsHandler streams the changes of the cluster state as server-sent
+// events, with the store revision of each change as its event ID
+func getEventsHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, "streaming is not supported", api.ErrCodeDefault)
+		return
+	}
+
+	rev, err := afterRevision(r)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	stream, err := events.Watch(ctx, rev)
+	switch err {
+	case nil:
+	case events.ErrCompacted:
+		restutils.SendHTTPError(ctx, w, http.StatusGone, err.Error(), api.ErrCodeDefault)
+		return
+	case events.ErrFutureRevision:
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	default:
+		logger.WithError(err).Error("failed to watch events")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case e, ok := <-stream.Events():
+			if !ok {
+				if err := stream.Err(); err != nil && err != context.Canceled {
+					logger.WithError(err).Error("event stream failed")
+					data, _ := json.Marshal(restutils.APIError{Code: api.ErrCodeDefault, Error: err.Error()})
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+					flusher.Flush()
+				}
+				return
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				logger.WithError(err).Error("failed to marshal event")
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Revision, e.Type, data)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		flusher.Flush()
+	}
+}
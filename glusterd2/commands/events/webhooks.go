@@ -0,0 +1,109 @@
+package eventcommands
+
+import (
+	"net/http"
+	"net/url"
+
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+)
+
+func createWebhookResp(w *webhook.Webhook) api.Webhook {
+	return api.Webhook{
+		ID:     w.ID,
+		URL:    w.URL,
+		Events: w.Events,
+	}
+}
+
+func addWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	var req api.WebhookAddReq
+	if err := restutils.UnmarshalRequest(r, &req); err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusUnprocessableEntity, errors.ErrJSONParsingFailed.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, "invalid webhook URL", api.ErrCodeDefault)
+		return
+	}
+
+	if restutils.IsDryRunRequest(r) {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
+	wh := &webhook.Webhook{
+		ID:     uuid.NewRandom(),
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	}
+	if err := webhook.Add(wh); err != nil {
+		logger.WithError(err).Error("failed to add webhook")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusCreated, createWebhookResp(wh))
+}
+
+func getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	webhooks, err := webhook.GetWebhooks()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	resp := make(api.WebhookListResp, 0, len(webhooks))
+	for _, wh := range webhooks {
+		resp = append(resp, createWebhookResp(wh))
+	}
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
+
+func deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	id := mux.Vars(r)["webhookid"]
+	if uuid.Parse(id) == nil {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, "invalid webhook ID", api.ErrCodeDefault)
+		return
+	}
+
+	var err error
+	dryRun := restutils.IsDryRunRequest(r)
+	if dryRun {
+		_, err = webhook.Get(id)
+	} else {
+		err = webhook.Delete(id)
+	}
+	if err == errors.ErrWebhookNotFound {
+		restutils.SendHTTPError(ctx, w, http.StatusNotFound, err.Error(), api.ErrCodeDefault)
+		return
+	} else if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	if dryRun {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,28 @@
+// Package jobcommands implements the commands to query asynchronous jobs
+package jobcommands
+
+import (
+	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
+)
+
+// Command is a holding struct used to implement the GlusterD Command interface
+type Command struct {
+}
+
+// Routes returns command routes. Required for the Command interface.
+func (c *Command) Routes() route.Routes {
+	return route.Routes{
+		route.Route{
+			Name:        "GetJob",
+			Method:      "GET",
+			Pattern:     "/jobs/{jobid}",
+			Version:     1,
+			HandlerFunc: getJobHandler,
+		},
+	}
+}
+
+// RegisterStepFuncs implements a required function for the Command interface
+func (c *Command) RegisterStepFuncs() {
+	return
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gluster/glusterd2/glusterd2/cluster"
 	"github.com/gluster/glusterd2/glusterd2/peer"
 	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
 	"github.com/gluster/glusterd2/glusterd2/store"
 	"github.com/gluster/glusterd2/pkg/api"
 	"github.com/gluster/glusterd2/pkg/errors"
 	"github.com/gluster/glusterd2/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type peerAddReq struct {
@@ -39,33 +42,53 @@ func addPeerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// A peer can have multiple addresses. For now, we use only the first
-	// address present in the req.Addresses list.
-	remotePeerAddress, err := utils.FormRemotePeerAddress(req.Addresses[0])
+	for _, address := range req.Addresses {
+		if _, err := utils.FormRemotePeerAddress(address); err != nil {
+			logger.WithError(err).WithField("address", address).Error("failed to parse peer address")
+			restutils.SendHTTPError(ctx, w, http.StatusBadRequest, "failed to parse remote address", api.ErrCodeDefault)
+			return
+		}
+	}
+
+	// The addresses are tried in order till one connects. The peer ID isn't
+	// known yet, so the connection is identified by the first address, and
+	// is evicted from the pool once the peer has joined.
+	client, err := getPeerServiceClient(req.Addresses[0], req.Addresses)
 	if err != nil {
-		logger.WithError(err).WithField("address", req.Addresses[0]).Error("failed to parse peer address")
-		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, "failed to parse remote address", api.ErrCodeDefault)
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
+	defer client.close()
+	logger = logger.WithField("peer", client.address)
+
+	newconfig := newStoreConfig(store.Store.Endpoints(), store.Store.Config())
+	logger.WithField("endpoints", newconfig.Endpoints).Debug("asking new peer to join cluster with given endpoints")
 
-	// TODO: Try all addresses till the first one connects
-	client, err := getPeerServiceClient(remotePeerAddress)
+	opVersion, err := cluster.OpVersion()
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
-	defer client.conn.Close()
-	logger = logger.WithField("peer", remotePeerAddress)
 
-	newconfig := &StoreConfig{store.Store.Endpoints()}
-	logger.WithField("endpoints", newconfig.Endpoints).Debug("asking new peer to join cluster with given endpoints")
+	// Dry runs stop once the new peer is known to be reachable, without
+	// asking it to join
+	if restutils.IsDryRunRequest(r) {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
 
-	// Ask the peer to join the cluster
-	rsp, err := client.JoinCluster(newconfig)
+	// Ask the peer to join the cluster. The peer checks that it supports
+	// the cluster op-version before joining.
+	rsp, err := client.JoinCluster(newconfig, opVersion)
 	if err != nil {
 		logger.WithError(err).Error("sending Join request failed")
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, "failed to send join cluster request", api.ErrCodeDefault)
 		return
+	} else if Error(rsp.Err) == ErrOpVersionUnsupported {
+		err = Error(rsp.Err)
+		logger.WithError(err).WithField("op-version", opVersion).Error("join request rejected")
+		restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
+		return
 	} else if Error(rsp.Err) != ErrNone {
 		err = Error(rsp.Err)
 		logger.WithError(err).Error("join request failed")
@@ -81,6 +104,15 @@ func addPeerHandler(w http.ResponseWriter, r *http.Request) {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, "new peer was added, but could not find peer in store. Try again later.", api.ErrCodeDefault)
 		return
 	}
+	// Peers running older versions of GlusterD don't check the cluster
+	// op-version before joining
+	if err := cluster.CheckPeer(newpeer); err != nil {
+		min, max := newpeer.OpVersions()
+		logger.WithError(err).WithFields(log.Fields{
+			"min-op-version": min,
+			"max-op-version": max,
+		}).Warn("new peer does not support the cluster op-version")
+	}
 
 	resp := createPeerAddResp(newpeer)
 	restutils.SendHTTPResponse(ctx, w, http.StatusCreated, resp)
@@ -26,6 +26,13 @@ func (c *Command) Routes() route.Routes {
 			Version:     1,
 			HandlerFunc: getPeersHandler,
 		},
+		route.Route{
+			Name:        "GetPeerHealth",
+			Method:      "GET",
+			Pattern:     "/peers/{peerid}/health",
+			Version:     1,
+			HandlerFunc: getPeerHealthHandler,
+		},
 		route.Route{
 			Name:        "EtcdHealthPeer",
 			Method:      "GET",
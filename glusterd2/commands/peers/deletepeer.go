@@ -4,12 +4,13 @@ import (
 	"net/http"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/health"
 	"github.com/gluster/glusterd2/glusterd2/peer"
 	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
 	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
 	"github.com/gluster/glusterd2/glusterd2/volume"
 	"github.com/gluster/glusterd2/pkg/api"
-	"github.com/gluster/glusterd2/pkg/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/pborman/uuid"
@@ -54,6 +55,16 @@ func deletePeerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Lock the whole cluster, so that no volume operation adds bricks on
+	// the peer while it is being removed
+	unlock, err := transaction.Lock(ctx, transaction.ClusterLockKey, transaction.LockExclusive)
+	if err != nil {
+		logger.WithError(err).Error("failed to lock cluster")
+		restutils.SendHTTPTxnError(ctx, w, err)
+		return
+	}
+	defer unlock()
+
 	// Check if any volumes exist with bricks on this peer
 	if exists, err := bricksExist(id); err != nil {
 		logger.WithError(err).Error("failed to check if bricks exist on peer")
@@ -65,26 +76,29 @@ func deletePeerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if restutils.IsDryRunRequest(r) {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
 	// Remove the peer details from the store
 	if err := peer.DeletePeer(id); err != nil {
 		logger.WithError(err).WithField("peer", id).Error("failed to remove peer from the store")
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
-
-	remotePeerAddress, err := utils.FormRemotePeerAddress(p.Addresses[0])
-	if err != nil {
-		logger.WithError(err).WithField("address", p.Addresses[0]).Error("failed to parse peer address")
-		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, "failed to parse remote address", api.ErrCodeDefault)
-		return
+	if err := health.Delete(p.ID); err != nil {
+		logger.WithError(err).WithField("peer", id).Warn("failed to remove health record of peer from the store")
 	}
 
-	client, err := getPeerServiceClient(remotePeerAddress)
+	// The peer is leaving the cluster, so the connection to it is evicted
+	// from the pool afterwards
+	client, err := getPeerServiceClient(id, p.Addresses)
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
-	defer client.conn.Close()
+	defer client.close()
 
 	// TODO: Need to do a better job of handling failures here. If this fails the
 	// peer being removed still thinks it's a part of the cluster, and could
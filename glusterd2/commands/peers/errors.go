@@ -11,6 +11,7 @@ const (
 	ErrHaveVolumes
 	ErrStoreReconfigFailed
 	ErrUnknownPeer
+	ErrOpVersionUnsupported
 	ErrMax
 )
 
@@ -22,6 +23,7 @@ func init() {
 	errorStrings[ErrHaveVolumes] = "peer has existing volumes"
 	errorStrings[ErrStoreReconfigFailed] = "store reconfigure failed on peer"
 	errorStrings[ErrUnknownPeer] = "request received from unknown peer"
+	errorStrings[ErrOpVersionUnsupported] = "peer does not support the cluster op-version"
 }
 
 func (e Error) String() string {
@@ -2,8 +2,10 @@ package peercommands
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/servers/peerrpc"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -15,31 +17,46 @@ var (
 )
 
 type peerSvcClnt struct { // this is not really a good name as it can be confused with PeerServiceClient, but there isn't anything better
-	conn    *grpc.ClientConn
 	client  PeerServiceClient
+	conn    *grpc.ClientConn
+	release func()
+	id      string
 	address string
 }
 
-// getPeerServiceClient returns a PeerServiceClient for the given address and the underlying grpc.ClientConn
-func getPeerServiceClient(address string) (*peerSvcClnt, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+// getPeerServiceClient returns a PeerServiceClient for the peer with the given
+// id and addresses, using a pooled connection. The id is used to identify the
+// connection in the pool, and can be an address for peers not in the cluster yet.
+func getPeerServiceClient(id string, addresses []string) (*peerSvcClnt, error) {
+	conn, release, err := peerrpc.GetConn(id, addresses)
 	if err != nil {
 		return nil, err
 	}
 
 	clnt := NewPeerServiceClient(conn)
 
-	return &peerSvcClnt{conn, clnt, address}, nil
+	return &peerSvcClnt{clnt, conn, release, id, strings.Join(addresses, ",")}, nil
+}
+
+// close releases the pooled connection used by the client, and evicts it from
+// the pool, as the peer is joining or leaving the cluster. Other users of the
+// connection can keep using it till they release it.
+func (pc *peerSvcClnt) close() {
+	pc.release()
+	peerrpc.EvictConn(pc.id)
 }
 
 // JoinCluster asks the remote peer to join the current cluster by reconfiguring the store with the given config
-func (pc *peerSvcClnt) JoinCluster(conf *StoreConfig) (*JoinRsp, error) {
+// The peer refuses to join if it doesn't support the given cluster op-version.
+func (pc *peerSvcClnt) JoinCluster(conf *StoreConfig, opVersion int) (*JoinRsp, error) {
 	args := &JoinReq{
 		gdctx.MyUUID.String(),
 		conf,
+		int32(opVersion),
 	}
 	rsp, err := pc.client.Join(context.TODO(), args)
 	if err != nil {
+		peerrpc.ReportError(pc.id, pc.conn, err)
 		log.WithError(err).WithFields(log.Fields{
 			"rpc":    "PeerService.Join",
 			"remote": pc.address,
@@ -55,6 +72,7 @@ func (pc *peerSvcClnt) LeaveCluster() (*LeaveRsp, error) {
 
 	rsp, err := pc.client.Leave(context.TODO(), args)
 	if err != nil {
+		peerrpc.ReportError(pc.id, pc.conn, err)
 		log.WithError(err).WithFields(log.Fields{
 			"rpc":    "PeerService.Leave",
 			"remote": pc.address,
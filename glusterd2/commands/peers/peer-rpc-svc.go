@@ -6,6 +6,7 @@ import (
 	"github.com/gluster/glusterd2/glusterd2/servers/peerrpc"
 	"github.com/gluster/glusterd2/glusterd2/store"
 	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/version"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -34,6 +35,7 @@ func (p *PeerService) Join(ctx context.Context, req *JoinReq) (*JoinRsp, error)
 	// 	- TODO: Ensure no ongoing operations (transactions/other peer requests) are happening
 	// 	- TODO: Check is peer is part of another cluster
 	// 	- Check if the peer has volumes
+	// 	- Check if the peer supports the op-version of the cluster
 	//	- Reconfigure the store with received configuration
 	// 	- Return your ID
 
@@ -47,6 +49,17 @@ func (p *PeerService) Join(ctx context.Context, req *JoinReq) (*JoinRsp, error)
 		return &JoinRsp{"", int32(ErrAnotherCluster)}, nil
 	}
 
+	// Requests from peers which don't send the op-version can't be
+	// checked
+	if req.OpVersion != 0 && (int(req.OpVersion) < version.MinOpVersion || int(req.OpVersion) > version.MaxOpVersion) {
+		logger.WithFields(log.Fields{
+			"op-version":     req.OpVersion,
+			"min-op-version": version.MinOpVersion,
+			"max-op-version": version.MaxOpVersion,
+		}).Info("rejecting join, cluster op-version is not supported")
+		return &JoinRsp{"", int32(ErrOpVersionUnsupported)}, nil
+	}
+
 	logger.Debug("all checks passed, joining new cluster")
 
 	if err := ReconfigureStore(req.Config); err != nil {
@@ -90,7 +103,7 @@ func (p *PeerService) Leave(ctx context.Context, req *LeaveReq) (*LeaveRsp, erro
 	logger.Debug("all checks passed, leaving cluster")
 
 	logger.Debug("reconfiguring store with defaults")
-	if err := ReconfigureStore(&StoreConfig{store.NewConfig().Endpoints}); err != nil {
+	if err := ReconfigureStore(nil); err != nil {
 		logger.WithError(err).Warn("failed to reconfigure store with defaults")
 		// XXX: We should probably keep retrying here?
 	}
@@ -98,18 +111,31 @@ func (p *PeerService) Leave(ctx context.Context, req *LeaveReq) (*LeaveRsp, erro
 }
 
 // ReconfigureStore reconfigures the store with the given store config, if no
-// store config is given uses the default
+// store config is given uses the default, with the TLS options of the GD2
+// config only
 func ReconfigureStore(c *StoreConfig) error {
 
+	// Prepare the new configuration, and check that its certificates can be
+	// loaded before the current store is destroyed
+	cfg := store.GetConfig()
+	if c == nil {
+		cfg.Endpoints = store.NewConfig().Endpoints
+		cfg.ResetTLS()
+	} else {
+		cfg.Endpoints = c.Endpoints
+		applyStoreTLS(cfg, c)
+	}
+	if err := cfg.ValidateTLS(); err != nil {
+		log.WithError(err).Error("cannot reconfigure store with invalid TLS options")
+		return err
+	}
+
 	// Destroy the current store first
 	log.Debug("destroying current store")
 	store.Destroy()
 	// TODO: Also need to destroy any old files in localstatedir (eg. volfiles)
 
-	// Restart the store with received configuration
-	cfg := store.GetConfig()
-	cfg.Endpoints = c.Endpoints
-
+	// Restart the store with the new configuration
 	if err := store.Init(cfg); err != nil {
 		log.WithError(err).WithField("endpoints", cfg.Endpoints).Error("failed to restart store with new endpoints")
 		// Restart store with default config
@@ -143,3 +169,43 @@ func ReconfigureStore(c *StoreConfig) error {
 
 	return nil
 }
+
+// newStoreConfig returns the store config sent to peers joining the cluster,
+// with the given endpoints and the TLS options of the given store config
+func newStoreConfig(endpoints []string, c store.Config) *StoreConfig {
+	return &StoreConfig{
+		Endpoints:      endpoints,
+		ClientCertFile: c.ClientCertFile,
+		ClientKeyFile:  c.ClientKeyFile,
+		ClientCAFile:   c.ClientCAFile,
+		ClientAutoTLS:  c.ClientAutoTLS,
+		PeerCertFile:   c.PeerCertFile,
+		PeerKeyFile:    c.PeerKeyFile,
+		PeerCAFile:     c.PeerCAFile,
+		PeerAutoTLS:    c.PeerAutoTLS,
+	}
+}
+
+// applyStoreTLS sets the TLS options received in the given store config, which
+// aren't set in the local store config. The certificates of the peer joining
+// the cluster are kept, and the certificates it doesn't have are expected at
+// the same paths as on the peer it was asked to join by.
+func applyStoreTLS(cfg *store.Config, c *StoreConfig) {
+	if cfg.ClientCertFile == "" && cfg.ClientKeyFile == "" {
+		cfg.ClientCertFile = c.ClientCertFile
+		cfg.ClientKeyFile = c.ClientKeyFile
+	}
+	if cfg.ClientCAFile == "" {
+		cfg.ClientCAFile = c.ClientCAFile
+	}
+	cfg.ClientAutoTLS = cfg.ClientAutoTLS || c.ClientAutoTLS
+
+	if cfg.PeerCertFile == "" && cfg.PeerKeyFile == "" {
+		cfg.PeerCertFile = c.PeerCertFile
+		cfg.PeerKeyFile = c.PeerKeyFile
+	}
+	if cfg.PeerCAFile == "" {
+		cfg.PeerCAFile = c.PeerCAFile
+	}
+	cfg.PeerAutoTLS = cfg.PeerAutoTLS || c.PeerAutoTLS
+}
@@ -37,7 +37,15 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type StoreConfig struct {
-	Endpoints []string `protobuf:"bytes,1,rep,name=Endpoints" json:"Endpoints,omitempty"`
+	Endpoints      []string `protobuf:"bytes,1,rep,name=Endpoints" json:"Endpoints,omitempty"`
+	ClientCertFile string   `protobuf:"bytes,2,opt,name=ClientCertFile" json:"ClientCertFile,omitempty"`
+	ClientKeyFile  string   `protobuf:"bytes,3,opt,name=ClientKeyFile" json:"ClientKeyFile,omitempty"`
+	ClientCAFile   string   `protobuf:"bytes,4,opt,name=ClientCAFile" json:"ClientCAFile,omitempty"`
+	ClientAutoTLS  bool     `protobuf:"varint,5,opt,name=ClientAutoTLS" json:"ClientAutoTLS,omitempty"`
+	PeerCertFile   string   `protobuf:"bytes,6,opt,name=PeerCertFile" json:"PeerCertFile,omitempty"`
+	PeerKeyFile    string   `protobuf:"bytes,7,opt,name=PeerKeyFile" json:"PeerKeyFile,omitempty"`
+	PeerCAFile     string   `protobuf:"bytes,8,opt,name=PeerCAFile" json:"PeerCAFile,omitempty"`
+	PeerAutoTLS    bool     `protobuf:"varint,9,opt,name=PeerAutoTLS" json:"PeerAutoTLS,omitempty"`
 }
 
 func (m *StoreConfig) Reset()                    { *m = StoreConfig{} }
@@ -52,9 +60,66 @@ func (m *StoreConfig) GetEndpoints() []string {
 	return nil
 }
 
+func (m *StoreConfig) GetClientCertFile() string {
+	if m != nil {
+		return m.ClientCertFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetClientKeyFile() string {
+	if m != nil {
+		return m.ClientKeyFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetClientCAFile() string {
+	if m != nil {
+		return m.ClientCAFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetClientAutoTLS() bool {
+	if m != nil {
+		return m.ClientAutoTLS
+	}
+	return false
+}
+
+func (m *StoreConfig) GetPeerCertFile() string {
+	if m != nil {
+		return m.PeerCertFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetPeerKeyFile() string {
+	if m != nil {
+		return m.PeerKeyFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetPeerCAFile() string {
+	if m != nil {
+		return m.PeerCAFile
+	}
+	return ""
+}
+
+func (m *StoreConfig) GetPeerAutoTLS() bool {
+	if m != nil {
+		return m.PeerAutoTLS
+	}
+	return false
+}
+
 type JoinReq struct {
-	PeerID string       `protobuf:"bytes,1,opt,name=PeerID" json:"PeerID,omitempty"`
-	Config *StoreConfig `protobuf:"bytes,2,opt,name=Config" json:"Config,omitempty"`
+	PeerID    string       `protobuf:"bytes,1,opt,name=PeerID" json:"PeerID,omitempty"`
+	Config    *StoreConfig `protobuf:"bytes,2,opt,name=Config" json:"Config,omitempty"`
+	OpVersion int32        `protobuf:"varint,3,opt,name=OpVersion" json:"OpVersion,omitempty"`
 }
 
 func (m *JoinReq) Reset()                    { *m = JoinReq{} }
@@ -76,6 +141,13 @@ func (m *JoinReq) GetConfig() *StoreConfig {
 	return nil
 }
 
+func (m *JoinReq) GetOpVersion() int32 {
+	if m != nil {
+		return m.OpVersion
+	}
+	return 0
+}
+
 type JoinRsp struct {
 	PeerID string `protobuf:"bytes,1,opt,name=PeerID" json:"PeerID,omitempty"`
 	Err    int32  `protobuf:"varint,2,opt,name=Err" json:"Err,omitempty"`
@@ -248,21 +320,28 @@ var _PeerService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("commands/peers/peer-rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 247 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x92, 0x4d, 0xce, 0xcf, 0xcd,
-	0x4d, 0xcc, 0x4b, 0x29, 0xd6, 0x2f, 0x48, 0x4d, 0x2d, 0x82, 0x90, 0xba, 0x45, 0x05, 0xc9, 0x7a,
-	0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x3c, 0x20, 0x3e, 0x4c, 0x89, 0x92, 0x36, 0x17, 0x77, 0x70,
-	0x49, 0x7e, 0x51, 0xaa, 0x73, 0x7e, 0x5e, 0x5a, 0x66, 0xba, 0x90, 0x0c, 0x17, 0xa7, 0x6b, 0x5e,
-	0x4a, 0x41, 0x7e, 0x66, 0x5e, 0x49, 0xb1, 0x04, 0xa3, 0x02, 0xb3, 0x06, 0x67, 0x10, 0x42, 0x40,
-	0x29, 0x84, 0x8b, 0xdd, 0x2b, 0x3f, 0x33, 0x2f, 0x28, 0xb5, 0x50, 0x48, 0x8c, 0x8b, 0x2d, 0x20,
-	0x35, 0xb5, 0xc8, 0xd3, 0x45, 0x82, 0x51, 0x81, 0x51, 0x83, 0x33, 0x08, 0xca, 0x13, 0x32, 0xe4,
-	0x62, 0x83, 0x18, 0x25, 0xc1, 0xa4, 0xc0, 0xa8, 0xc1, 0x6d, 0x24, 0xa9, 0x87, 0x6c, 0x9d, 0x1e,
-	0x92, 0x5d, 0x41, 0x50, 0x85, 0x4a, 0xc6, 0x50, 0x53, 0x8b, 0x0b, 0x70, 0x9a, 0x2a, 0xc0, 0xc5,
-	0xec, 0x5a, 0x54, 0x04, 0x36, 0x92, 0x35, 0x08, 0xc4, 0x54, 0x52, 0xe2, 0xe2, 0xf0, 0x49, 0x4d,
-	0x2c, 0x4b, 0xc5, 0xe3, 0x16, 0x25, 0x19, 0x98, 0x9a, 0xe2, 0x02, 0x98, 0x09, 0x8c, 0x70, 0x13,
-	0x8c, 0x1a, 0x18, 0xb9, 0xb8, 0x41, 0x0a, 0x83, 0x53, 0x8b, 0xca, 0x32, 0x93, 0x53, 0x85, 0xcc,
-	0xb8, 0x58, 0x40, 0xce, 0x10, 0x12, 0x45, 0x75, 0x31, 0xd4, 0xc3, 0x52, 0xd8, 0x84, 0x8b, 0x0b,
-	0x94, 0x18, 0x84, 0x2c, 0xb9, 0x58, 0xc1, 0xb6, 0x08, 0x89, 0xa1, 0xaa, 0x80, 0x39, 0x4f, 0x0a,
-	0xab, 0x38, 0x48, 0x6b, 0x12, 0x1b, 0x38, 0x46, 0x8c, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0x88,
-	0x1d, 0x73, 0x8f, 0xb2, 0x01, 0x00, 0x00,
+	// 358 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x7d, 0x92, 0xd1, 0x4b, 0xc2, 0x50,
+	0x14, 0xc6, 0x9b, 0xba, 0xa9, 0x47, 0x8b, 0x38, 0x90, 0x98, 0x58, 0xc8, 0x25, 0xa2, 0x97, 0x8c,
+	0x14, 0x82, 0x1e, 0xc5, 0x0c, 0x2a, 0xa1, 0x98, 0xd1, 0xbb, 0xe9, 0x2d, 0x06, 0xba, 0x7b, 0xbd,
+	0x5b, 0x42, 0x6f, 0xfd, 0x7f, 0xfd, 0x53, 0xdd, 0x7b, 0xb6, 0xb9, 0x2d, 0xac, 0x97, 0xb1, 0xf3,
+	0xdb, 0x77, 0xbf, 0xf3, 0xdd, 0x73, 0x06, 0x47, 0x33, 0xb1, 0x5c, 0x4e, 0xfd, 0x79, 0x70, 0x21,
+	0x39, 0x57, 0xd1, 0xf3, 0x5c, 0xc9, 0x59, 0x57, 0x2a, 0x11, 0x0a, 0xac, 0x9b, 0x3a, 0x91, 0xb0,
+	0xef, 0x02, 0xd4, 0x26, 0xa1, 0x50, 0x7c, 0x28, 0xfc, 0x37, 0xef, 0x1d, 0xdb, 0x50, 0x1d, 0xf9,
+	0x73, 0x29, 0x3c, 0x3f, 0x0c, 0x9a, 0x56, 0xa7, 0x78, 0x56, 0x75, 0x53, 0x80, 0xa7, 0xb0, 0x37,
+	0x5c, 0x78, 0xdc, 0x0f, 0x87, 0x5c, 0x85, 0xb7, 0xde, 0x82, 0x37, 0x0b, 0x1d, 0x4b, 0x4b, 0x7e,
+	0x51, 0x3c, 0x81, 0xdd, 0x88, 0x3c, 0xf0, 0x4f, 0x92, 0x15, 0x49, 0x96, 0x87, 0xc8, 0xa0, 0x1e,
+	0x9f, 0x1b, 0x90, 0xa8, 0x44, 0xa2, 0x1c, 0x4b, 0x9d, 0x06, 0x1f, 0xa1, 0x78, 0x1e, 0x4f, 0x9a,
+	0xb6, 0x16, 0x55, 0xdc, 0x3c, 0x34, 0x4e, 0x4f, 0xfa, 0x56, 0x9b, 0x54, 0x4e, 0xe4, 0x94, 0x65,
+	0xd8, 0x81, 0x9a, 0xa9, 0x93, 0x44, 0x65, 0x92, 0x64, 0x11, 0x1e, 0x03, 0xd0, 0x89, 0x28, 0x4d,
+	0x85, 0x04, 0x19, 0x92, 0x38, 0x24, 0x49, 0xaa, 0x94, 0x24, 0x8b, 0x98, 0x82, 0xf2, 0xbd, 0x9e,
+	0x94, 0xcb, 0x57, 0xd8, 0x00, 0xc7, 0x7c, 0xb9, 0xbb, 0xd1, 0x53, 0x34, 0x46, 0x71, 0x85, 0x97,
+	0xe0, 0x44, 0xa3, 0xa6, 0xd1, 0xd5, 0x7a, 0x87, 0xdd, 0xec, 0x3e, 0xba, 0x99, 0x5d, 0xb8, 0x4e,
+	0xba, 0x93, 0x47, 0xf9, 0xa2, 0x57, 0xe9, 0x09, 0x9f, 0x26, 0x69, 0xbb, 0x29, 0x60, 0xfd, 0xb8,
+	0x67, 0x20, 0xff, 0xec, 0xb9, 0x0f, 0xc5, 0x91, 0x52, 0xd4, 0xd0, 0x76, 0xcd, 0x2b, 0x63, 0x50,
+	0x19, 0xf3, 0xe9, 0x9a, 0xff, 0x93, 0x94, 0xb5, 0x13, 0x8d, 0x76, 0x8e, 0x1d, 0xac, 0x8d, 0x43,
+	0xef, 0xcb, 0x8a, 0xa6, 0x31, 0xe1, 0x6a, 0xed, 0xcd, 0x38, 0x5e, 0x41, 0xc9, 0xc4, 0xc0, 0x83,
+	0xfc, 0x7d, 0xe2, 0x71, 0xb4, 0xb6, 0xe1, 0x40, 0xb2, 0x1d, 0xbc, 0x06, 0x9b, 0xba, 0x60, 0x23,
+	0xaf, 0x48, 0xe2, 0xb5, 0xb6, 0x72, 0x73, 0xf4, 0xd5, 0xa1, 0x1f, 0xba, 0xff, 0x03, 0xe1, 0xae,
+	0x88, 0x6b, 0xf1, 0x02, 0x00, 0x00,
 }
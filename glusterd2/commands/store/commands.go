@@ -0,0 +1,50 @@
+// Package storecommands implements the commands to view and manage the
+// embedded store, whose etcd servers are run by some of the peers
+package storecommands
+
+import (
+	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
+)
+
+// Command is a holding struct used to implement the GlusterD Command interface
+type Command struct {
+}
+
+// Routes returns command routes. Required for the Command interface.
+func (c *Command) Routes() route.Routes {
+	return route.Routes{
+		route.Route{
+			Name:        "GetStoreMembers",
+			Method:      "GET",
+			Pattern:     "/store/members",
+			Version:     1,
+			HandlerFunc: getMembersHandler,
+		},
+		route.Route{
+			Name:        "SetStoreMemberNomination",
+			Method:      "PUT",
+			Pattern:     "/store/members/{peerid}",
+			Version:     1,
+			HandlerFunc: setMemberHandler,
+		},
+		route.Route{
+			Name:        "SetStoreIdealSize",
+			Method:      "PUT",
+			Pattern:     "/store/ideal-size",
+			Version:     1,
+			HandlerFunc: setIdealSizeHandler,
+		},
+		route.Route{
+			Name:        "SaveStoreSnapshot",
+			Method:      "POST",
+			Pattern:     "/store/snapshot",
+			Version:     1,
+			HandlerFunc: saveSnapshotHandler,
+		},
+	}
+}
+
+// RegisterStepFuncs implements a required function for the Command interface
+func (c *Command) RegisterStepFuncs() {
+	return
+}
@@ -0,0 +1,185 @@
+package storecommands
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/elasticetcd"
+	"github.com/gluster/glusterd2/pkg/errors"
+
+	"github.com/gorilla/mux"
+)
+
+// elastic returns the elastic etcd of the embedded store, or sends an error
+// if the store is not embedded
+func elastic(ctx context.Context, w http.ResponseWriter) *elasticetcd.ElasticEtcd {
+	ee, err := store.Store.Elastic()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return nil
+	}
+	return ee
+}
+
+func membersResp(ctx context.Context, ee *elasticetcd.ElasticEtcd) (*api.StoreMembersResp, error) {
+	size, err := ee.IdealSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := ee.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &api.StoreMembersResp{
+		IdealSize: size,
+		Members:   make([]api.StoreMember, 0, len(members)),
+	}
+	for _, m := range members {
+		if m.Leader {
+			resp.Leader = m.Name
+		}
+		resp.Members = append(resp.Members, api.StoreMember{
+			ID:         m.Name,
+			Volunteer:  m.Volunteer,
+			Nominee:    m.Nominee,
+			Leader:     m.Leader,
+			Nomination: api.NominationPolicy(m.Policy),
+			Zone:       m.Domain.Zone,
+			Rack:       m.Domain.Rack,
+			PeerURLs:   m.PURLs,
+			ClientURLs: m.CURLs,
+			Server:     m.Server,
+			Healthy:    m.Healthy,
+			Error:      m.Error,
+		})
+	}
+	return resp, nil
+}
+
+func getMembersHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ee := elastic(ctx, w)
+	if ee == nil {
+		return
+	}
+
+	resp, err := membersResp(ctx, ee)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
+
+func setMemberHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	ee := elastic(ctx, w)
+	if ee == nil {
+		return
+	}
+
+	p, err := peer.GetPeerF(mux.Vars(r)["peerid"])
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusNotFound, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	var req api.StoreMemberReq
+	if err := restutils.UnmarshalRequest(r, &req); err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusUnprocessableEntity, errors.ErrJSONParsingFailed.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	dryRun := restutils.IsDryRunRequest(r)
+	policy := elasticetcd.NominationPolicy(req.Nomination)
+	if dryRun {
+		err = policy.Validate()
+	} else {
+		// The embedded store instances are named after the IDs of the peers
+		err = ee.SetNominationPolicy(ctx, p.ID.String(), policy)
+	}
+	switch err {
+	case nil:
+	case elasticetcd.ErrInvalidNominationPolicy:
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	default:
+		logger.WithError(err).WithField("peer", p.ID).Error("failed to set store nomination policy")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	if dryRun {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
+	resp, err := membersResp(ctx, ee)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
+
+func setIdealSizeHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	ee := elastic(ctx, w)
+	if ee == nil {
+		return
+	}
+
+	var req api.StoreIdealSizeReq
+	if err := restutils.UnmarshalRequest(r, &req); err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusUnprocessableEntity, errors.ErrJSONParsingFailed.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	dryRun := restutils.IsDryRunRequest(r)
+	var err error
+	if dryRun {
+		err = elasticetcd.ValidateIdealSize(req.IdealSize)
+	} else {
+		err = ee.SetIdealSize(ctx, req.IdealSize)
+	}
+	switch err {
+	case nil:
+	case elasticetcd.ErrInvalidIdealSize:
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	default:
+		logger.WithError(err).Error("failed to set store ideal size")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	if dryRun {
+		restutils.SendHTTPDryRunValid(ctx, w)
+		return
+	}
+
+	// The nominations are done asynchronously by the leader, the members
+	// show the progress
+	resp, err := membersResp(ctx, ee)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusAccepted, resp)
+}
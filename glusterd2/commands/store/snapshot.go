@@ -0,0 +1,44 @@
+package storecommands
+
+import (
+	"net/http"
+	"os"
+
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/errors"
+)
+
+func saveSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+	logger := restutils.GetReqLogger(ctx)
+
+	// Nothing can be checked about a snapshot without saving it
+	if restutils.IsDryRunRequest(r) {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, errors.ErrDryRunUnsupported.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	// Snapshots can be taken of external etcd clusters too, so the store
+	// need not be embedded
+	p, err := store.Store.SaveSnapshot(ctx)
+	if err != nil {
+		logger.WithError(err).Error("failed to save store snapshot")
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	resp := &api.StoreSnapshotResp{
+		Path: p,
+		Size: fi.Size(),
+	}
+	restutils.SendHTTPResponse(ctx, w, http.StatusCreated, resp)
+}
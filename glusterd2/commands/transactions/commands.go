@@ -0,0 +1,28 @@
+// Package transactioncommands implements the transaction history commands
+package transactioncommands
+
+import (
+	"github.com/gluster/glusterd2/glusterd2/servers/rest/route"
+)
+
+// Command is a holding struct used to implement the GlusterD Command interface
+type Command struct {
+}
+
+// Routes returns command routes. Required for the Command interface.
+func (c *Command) Routes() route.Routes {
+	return route.Routes{
+		route.Route{
+			Name:        "GetTransactions",
+			Method:      "GET",
+			Pattern:     "/transactions",
+			Version:     1,
+			HandlerFunc: getTransactionsHandler,
+		},
+	}
+}
+
+// RegisterStepFuncs implements a required function for the Command interface
+func (c *Command) RegisterStepFuncs() {
+	return
+}
@@ -0,0 +1,78 @@
+package transactioncommands
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/pborman/uuid"
+)
+
+// parseHistoryFilter creates a transaction.HistoryFilter from the query
+// parameters of the request
+func parseHistoryFilter(q url.Values) (*transaction.HistoryFilter, error) {
+	f := &transaction.HistoryFilter{
+		User:     q.Get("user"),
+		Resource: q.Get("resource"),
+	}
+
+	for param, id := range map[string]*uuid.UUID{"originator": &f.Originator, "node": &f.Node} {
+		if v := q.Get(param); v != "" {
+			if *id = uuid.Parse(v); *id == nil {
+				return nil, fmt.Errorf("invalid %s: %s", param, v)
+			}
+		}
+	}
+
+	if v := q.Get("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success: %s", v)
+		}
+		f.Success = &success
+	}
+
+	for param, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(param); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("invalid %s, must be in RFC3339 format: %s", param, v)
+			}
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit: %s", v)
+		}
+		f.Limit = limit
+	}
+
+	return f, nil
+}
+
+func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	filter, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	entries, err := transaction.GetHistory(filter)
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	restutils.SendHTTPResponse(ctx, w, http.StatusOK, entries)
+}
@@ -1,9 +1,11 @@
 package volumecommands
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
+	"github.com/gluster/glusterd2/glusterd2/brick"
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	"github.com/gluster/glusterd2/glusterd2/servers/sunrpc"
 	"github.com/gluster/glusterd2/glusterd2/transaction"
@@ -56,6 +58,40 @@ func areOptionNamesValid(optsFromReq map[string]string) error {
 	return nil
 }
 
+type unsupportedOptionError struct {
+	option    string
+	opVersion uint32
+}
+
+func (e unsupportedOptionError) Error() string {
+	return fmt.Sprintf("option %s requires cluster op-version %d", e.option, e.opVersion)
+}
+
+// areOptionsSupported checks that the given options were introduced at or
+// before the given cluster op-version, so that they are understood by all
+// the peers. The option names must have been validated.
+func areOptionsSupported(optsFromReq map[string]string, opVersion int) error {
+
+	for o := range optsFromReq {
+		_, xlatorType, xlatorOption := volume.SplitVolumeOptionName(o)
+
+		for _, option := range xlator.AllOptions[xlatorType] {
+			// The first op-version is the one the option was
+			// introduced in, the others are of backports
+			if len(option.OpVersion) == 0 || option.OpVersion[0] <= uint32(opVersion) {
+				continue
+			}
+			for _, key := range option.Key {
+				if xlatorOption == key {
+					return unsupportedOptionError{option: o, opVersion: option.OpVersion[0]}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func generateBrickVolfiles(c transaction.TxnCtx) error {
 
 	// This is used in volume-create and volume-set
@@ -124,3 +160,41 @@ func storeVolume(c transaction.TxnCtx) error {
 
 	return nil
 }
+
+// getVolumeFromCtx returns the volume named by the "volname" key of the
+// transaction context, as it is in the store
+func getVolumeFromCtx(c transaction.TxnCtx) (*volume.Volinfo, error) {
+	var volname string
+	if err := c.Get("volname", &volname); err != nil {
+		return nil, err
+	}
+	return volume.GetVolume(volname)
+}
+
+// markBricks sets the volume ID xattr on the local bricks among the given
+// bricks, marking them as in use by the volume
+func markBricks(bricks []brick.Brickinfo, volID uuid.UUID) error {
+	for _, b := range bricks {
+		if !uuid.Equal(b.NodeID, gdctx.MyUUID) {
+			continue
+		}
+		if err := utils.SetVolumeIDXattr(b.Path, volID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarkBricks removes the volume ID xattr set by markBricks from the local
+// bricks among the given bricks
+func unmarkBricks(bricks []brick.Brickinfo) error {
+	for _, b := range bricks {
+		if !uuid.Equal(b.NodeID, gdctx.MyUUID) {
+			continue
+		}
+		if err := utils.RemoveVolumeIDXattr(b.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package volumecommands
+
+import (
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/xlator"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAreOptionsSupported validates that options introduced after the cluster
+// op-version are rejected
+func TestAreOptionsSupported(t *testing.T) {
+	oldOptions := xlator.AllOptions
+	defer func() {
+		xlator.AllOptions = oldOptions
+	}()
+	xlator.AllOptions = map[string][]xlator.Option{
+		"afr": {
+			{Key: []string{"eager-lock"}, OpVersion: []uint32{30000}},
+			{Key: []string{"new-option"}, OpVersion: []uint32{40100, 31200}},
+		},
+	}
+
+	assert.Nil(t, areOptionsSupported(map[string]string{"afr.eager-lock": "on"}, 40000))
+	assert.Nil(t, areOptionsSupported(map[string]string{"gfproxy.afr.new-option": "on"}, 40100))
+
+	err := areOptionsSupported(map[string]string{"afr.new-option": "on"}, 40000)
+	assert.Equal(t, unsupportedOptionError{option: "afr.new-option", opVersion: 40100}, err)
+}
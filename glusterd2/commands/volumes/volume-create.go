@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gluster/glusterd2/glusterd2/cluster"
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
 	"github.com/gluster/glusterd2/glusterd2/transaction"
@@ -103,7 +104,7 @@ func validateVolumeCreate(c transaction.TxnCtx) error {
 	}
 
 	// FIXME: Return values of this function are inconsistent and unused
-	if _, err = volume.ValidateBrickEntriesFunc(volinfo.Bricks, volinfo.ID, req.Force); err != nil {
+	if _, err = volume.ValidateBrickEntriesFunc(volinfo.Bricks, req.Force); err != nil {
 		c.Logger().WithError(err).WithField(
 			"volume", volinfo.Name).Debug("validateVolumeCreate: failed to validate bricks")
 		return err
@@ -112,6 +113,29 @@ func validateVolumeCreate(c transaction.TxnCtx) error {
 	return nil
 }
 
+// markVolumeBricks marks the local bricks of the new volume as in use by it.
+// This is a step of its own, after the validation, so that a dry run leaves
+// the bricks untouched.
+func markVolumeBricks(c transaction.TxnCtx) error {
+
+	var volinfo volume.Volinfo
+	if err := c.Get("volinfo", &volinfo); err != nil {
+		return err
+	}
+
+	return markBricks(volinfo.Bricks, volinfo.ID)
+}
+
+func unmarkVolumeBricks(c transaction.TxnCtx) error {
+
+	var volinfo volume.Volinfo
+	if err := c.Get("volinfo", &volinfo); err != nil {
+		return err
+	}
+
+	return unmarkBricks(volinfo.Bricks)
+}
+
 func rollBackVolumeCreate(c transaction.TxnCtx) error {
 
 	var volinfo volume.Volinfo
@@ -124,8 +148,6 @@ func rollBackVolumeCreate(c transaction.TxnCtx) error {
 			continue
 		}
 		volgen.DeleteBrickVolfile(&b)
-		// TODO: Clean xattrs set if any. ValidateBrickEntriesFunc()
-		// does a lot of things that it's not supposed to do.
 	}
 
 	return nil
@@ -137,6 +159,8 @@ func registerVolCreateStepFuncs() {
 		sf   transaction.StepFunc
 	}{
 		{"vol-create.Validate", validateVolumeCreate},
+		{"vol-create.MarkBricks", markVolumeBricks},
+		{"vol-create.UnmarkBricks", unmarkVolumeBricks},
 		{"vol-create.GenerateBrickVolfiles", generateBrickVolfiles},
 		{"vol-create.StoreVolume", storeVolume},
 		{"vol-create.Rollback", rollBackVolumeCreate},
@@ -178,26 +202,47 @@ func volumeCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txn := transaction.NewTxn(ctx)
+	clusterOpVersion, err := cluster.OpVersion()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+	if err := areOptionsSupported(req.Options, clusterOpVersion); err != nil {
+		logger.WithError(err).Error("volume option not supported by the cluster op-version")
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	txn := transaction.NewTxnWithInlineCtx(ctx)
 	defer txn.Cleanup()
 
-	lock, unlock, err := transaction.CreateLockSteps(req.Name)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(req.Name))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
 
 	txn.Nodes = nodes
+	// Once the bricks are validated, generating their volfiles and marking
+	// them are independent of each other
 	txn.Steps = []*transaction.Step{
 		lock,
 		{
-			DoFunc: "vol-create.Validate",
-			Nodes:  txn.Nodes,
+			DoFunc:   "vol-create.Validate",
+			Nodes:    txn.Nodes,
+			Validate: true,
 		},
 		{
 			DoFunc:   "vol-create.GenerateBrickVolfiles",
 			UndoFunc: "vol-create.Rollback",
 			Nodes:    txn.Nodes,
+			Parallel: true,
+		},
+		{
+			DoFunc:   "vol-create.MarkBricks",
+			UndoFunc: "vol-create.UnmarkBricks",
+			Nodes:    txn.Nodes,
+			Parallel: true,
 		},
 		{
 			DoFunc: "vol-create.StoreVolume",
@@ -227,14 +272,17 @@ func volumeCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
 	c, err := txn.Do()
 	if err != nil {
 		logger.WithError(err).Error("volume create transaction failed")
-		if err == transaction.ErrLockTimeout {
-			restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
-		} else {
-			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
-		}
+		restutils.SendHTTPTxnError(ctx, w, err)
 		return
 	}
 
@@ -83,7 +83,7 @@ func TestValidateVolumeCreate(t *testing.T) {
 	c := transaction.NewMockCtx()
 	c.Set("req", msg)
 
-	defer testutils.Patch(&volume.ValidateBrickEntriesFunc, func(bricks []brick.Brickinfo, volID uuid.UUID, force bool) (int, error) {
+	defer testutils.Patch(&volume.ValidateBrickEntriesFunc, func(bricks []brick.Brickinfo, force bool) (int, error) {
 		return 0, nil
 	}).Restore()
 	defer testutils.Patch(&peer.GetPeerIDByAddrF, peer.GetPeerIDByAddrMockGood).Restore()
@@ -97,7 +97,7 @@ func TestValidateVolumeCreate(t *testing.T) {
 	assert.Nil(t, e)
 
 	// Mock validateBrickEntries failure
-	defer testutils.Patch(&volume.ValidateBrickEntriesFunc, func(bricks []brick.Brickinfo, volID uuid.UUID, force bool) (int, error) {
+	defer testutils.Patch(&volume.ValidateBrickEntriesFunc, func(bricks []brick.Brickinfo, force bool) (int, error) {
 		return 0, errBad
 	}).Restore()
 	e = validateVolumeCreate(c)
@@ -9,11 +9,25 @@ import (
 	"github.com/gluster/glusterd2/glusterd2/volgen"
 	"github.com/gluster/glusterd2/glusterd2/volume"
 	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/errors"
 
 	"github.com/gorilla/mux"
 	"github.com/pborman/uuid"
 )
 
+// validateVolumeDelete checks, with the volume locked, that the volume is
+// still there and isn't started
+func validateVolumeDelete(c transaction.TxnCtx) error {
+	vol, err := getVolumeFromCtx(c)
+	if err != nil {
+		return err
+	}
+	if vol.State == volume.VolStarted {
+		return errors.ErrVolNotStopped
+	}
+	return nil
+}
+
 func deleteVolfiles(c transaction.TxnCtx) error {
 
 	var volname string
@@ -62,6 +76,7 @@ func registerVolDeleteStepFuncs() {
 		name string
 		sf   transaction.StepFunc
 	}{
+		{"vol-delete.Validate", validateVolumeDelete},
 		{"vol-delete.Commit", deleteVolfiles},
 		{"vol-delete.Store", deleteVolume},
 	}
@@ -83,13 +98,13 @@ func volumeDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if vol.State == volume.VolStarted {
-		restutils.SendHTTPError(ctx, w, http.StatusForbidden, "volume is not stopped", api.ErrCodeDefault)
+		restutils.SendHTTPError(ctx, w, http.StatusForbidden, errors.ErrVolNotStopped.Error(), api.ErrCodeDefault)
 		return
 	}
 
 	txn := transaction.NewTxn(ctx)
 	defer txn.Cleanup()
-	lock, unlock, err := transaction.CreateLockSteps(volname)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(volname))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -97,6 +112,11 @@ func volumeDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "vol-delete.Validate",
+			Nodes:    []uuid.UUID{gdctx.MyUUID},
+			Validate: true,
+		},
 		{
 			DoFunc: "vol-delete.Commit",
 			Nodes:  txn.Nodes,
@@ -109,14 +129,18 @@ func volumeDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	txn.Ctx.Set("volname", volname)
+
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
 	if _, err = txn.Do(); err != nil {
 		logger.WithError(err).WithField(
 			"volume", volname).Error("failed to delete the volume")
-		if err == transaction.ErrLockTimeout {
-			restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
-		} else {
-			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
-		}
+		restutils.SendHTTPTxnError(ctx, w, err)
 		return
 	}
 
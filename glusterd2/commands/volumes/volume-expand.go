@@ -25,7 +25,7 @@ func checkBricksOnExpand(c transaction.TxnCtx) error {
 	}
 
 	// TODO: Fix return values
-	if _, err := volume.ValidateBrickEntriesFunc(newBricks, newBricks[0].VolumeID, true); err != nil {
+	if _, err := volume.ValidateBrickEntriesFunc(newBricks, true); err != nil {
 		return err
 	}
 
@@ -44,7 +44,10 @@ func startBricksOnExpand(c transaction.TxnCtx) error {
 		return err
 	}
 
-	// Generate brick volfiles for the new bricks
+	// Mark the new bricks as in use, and generate their brick volfiles
+	if err := markBricks(newBricks, volinfo.ID); err != nil {
+		return err
+	}
 	for _, b := range newBricks {
 		if !uuid.Equal(b.NodeID, gdctx.MyUUID) {
 			continue
@@ -118,7 +121,7 @@ func undoStartBricksOnExpand(c transaction.TxnCtx) error {
 		}
 	}
 
-	return nil
+	return unmarkBricks(newBricks)
 }
 
 func updateVolinfoOnExpand(c transaction.TxnCtx) error {
@@ -220,7 +223,7 @@ func volumeExpandHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	lock, unlock, err := transaction.CreateLockSteps(volinfo.Name)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(volinfo.Name))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -240,8 +243,9 @@ func volumeExpandHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Steps = []*transaction.Step{
 		lock,
 		{
-			DoFunc: "vol-expand.CheckBrick",
-			Nodes:  txn.Nodes,
+			DoFunc:   "vol-expand.CheckBrick",
+			Nodes:    txn.Nodes,
+			Validate: true,
 		},
 		{
 			DoFunc:   "vol-expand.StartBrick",
@@ -281,26 +285,50 @@ func volumeExpandHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err = txn.Do(); err != nil {
-		logger.WithError(err).Error("volume expand transaction failed")
-		if err == transaction.ErrLockTimeout {
-			restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
-		} else {
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
+	if restutils.IsAsyncRequest(r) {
+		job, err := txn.DoAsync(func(_ transaction.TxnCtx, err error) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+			return getVolumeExpandResp(volname)
+		})
+		if err != nil {
 			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+			return
 		}
+		restutils.SendHTTPJobResponse(ctx, w, job)
 		return
 	}
 
-	newvolinfo, err := volume.GetVolume(volname)
+	if _, err = txn.Do(); err != nil {
+		logger.WithError(err).Error("volume expand transaction failed")
+		restutils.SendHTTPTxnError(ctx, w, err)
+		return
+	}
+
+	resp, err := getVolumeExpandResp(volname)
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
 	}
-
-	resp := createVolumeExpandResp(newvolinfo)
 	restutils.SendHTTPResponse(ctx, w, http.StatusOK, resp)
 }
 
+func getVolumeExpandResp(volname string) (*api.VolumeExpandResp, error) {
+	newvolinfo, err := volume.GetVolume(volname)
+	if err != nil {
+		return nil, err
+	}
+	return createVolumeExpandResp(newvolinfo), nil
+}
+
 func createVolumeExpandResp(v *volume.Volinfo) *api.VolumeExpandResp {
 	return (*api.VolumeExpandResp)(createVolumeInfoResp(v))
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gluster/glusterd2/glusterd2/cluster"
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	"github.com/gluster/glusterd2/glusterd2/peer"
 	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
@@ -16,11 +17,30 @@ import (
 	"github.com/pborman/uuid"
 )
 
+// validateVolumeOptions checks, with the volume locked, that the volume is
+// still there and that its options are supported by the cluster op-version
+func validateVolumeOptions(c transaction.TxnCtx) error {
+	var volinfo volume.Volinfo
+	if err := c.Get("volinfo", &volinfo); err != nil {
+		return err
+	}
+	if !volume.ExistsFunc(volinfo.Name) {
+		return errors.ErrVolNotFound
+	}
+
+	clusterOpVersion, err := cluster.OpVersion()
+	if err != nil {
+		return err
+	}
+	return areOptionsSupported(volinfo.Options, clusterOpVersion)
+}
+
 func registerVolOptionStepFuncs() {
 	var sfs = []struct {
 		name string
 		sf   transaction.StepFunc
 	}{
+		{"vol-option.Validate", validateVolumeOptions},
 		{"vol-option.UpdateVolinfo", storeVolume},
 		{"vol-option.RegenerateVolfiles", generateBrickVolfiles},
 		{"vol-option.NotifyVolfileChange", notifyVolfileChange},
@@ -54,7 +74,18 @@ func volumeOptionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	lock, unlock, err := transaction.CreateLockSteps(volinfo.Name)
+	clusterOpVersion, err := cluster.OpVersion()
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
+	if err := areOptionsSupported(req.Options, clusterOpVersion); err != nil {
+		logger.WithError(err).Error("volume option not supported by the cluster op-version")
+		restutils.SendHTTPError(ctx, w, http.StatusBadRequest, err.Error(), api.ErrCodeDefault)
+		return
+	}
+
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(volinfo.Name))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -74,6 +105,11 @@ func volumeOptionsHandler(w http.ResponseWriter, r *http.Request) {
 
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "vol-option.Validate",
+			Nodes:    []uuid.UUID{gdctx.MyUUID},
+			Validate: true,
+		},
 		{
 			DoFunc: "vol-option.UpdateVolinfo",
 			Nodes:  []uuid.UUID{gdctx.MyUUID},
@@ -108,13 +144,16 @@ func volumeOptionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
 	if _, err := txn.Do(); err != nil {
 		logger.WithError(err).Error("volume option transaction failed")
-		if err == transaction.ErrLockTimeout {
-			restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
-		} else {
-			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
-		}
+		restutils.SendHTTPTxnError(ctx, w, err)
 		return
 	}
 
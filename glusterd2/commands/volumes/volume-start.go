@@ -2,6 +2,7 @@ package volumecommands
 
 import (
 	"net/http"
+	"os"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	restutils "github.com/gluster/glusterd2/glusterd2/servers/rest/utils"
@@ -15,6 +16,31 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// validateVolumeStart checks, with the volume locked, that the volume isn't
+// started yet and that its local bricks are present
+func validateVolumeStart(c transaction.TxnCtx) error {
+	vol, err := getVolumeFromCtx(c)
+	if err != nil {
+		return err
+	}
+	if vol.State == volume.VolStarted {
+		return errors.ErrVolAlreadyStarted
+	}
+
+	for _, b := range vol.Bricks {
+		if !uuid.Equal(b.NodeID, gdctx.MyUUID) {
+			continue
+		}
+		if _, err := os.Stat(b.Path); err != nil {
+			c.Logger().WithError(err).WithField(
+				"brick", b.String()).Debug("validateVolumeStart: brick path is not accessible")
+			return err
+		}
+	}
+
+	return nil
+}
+
 func startAllBricks(c transaction.TxnCtx) error {
 	var volname string
 	if err := c.Get("volname", &volname); err != nil {
@@ -85,6 +111,7 @@ func stopAllBricks(c transaction.TxnCtx) error {
 }
 
 func registerVolStartStepFuncs() {
+	transaction.RegisterStepFunc(validateVolumeStart, "vol-start.Validate")
 	transaction.RegisterStepFunc(startAllBricks, "vol-start.Commit")
 	transaction.RegisterStepFunc(stopAllBricks, "vol-start.Undo")
 }
@@ -108,7 +135,7 @@ func volumeStartHandler(w http.ResponseWriter, r *http.Request) {
 	// A simple one-step transaction to start the brick processes
 	txn := transaction.NewTxn(ctx)
 	defer txn.Cleanup()
-	lock, unlock, err := transaction.CreateLockSteps(volname)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(volname))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -116,6 +143,11 @@ func volumeStartHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "vol-start.Validate",
+			Nodes:    txn.Nodes,
+			Validate: true,
+		},
 		{
 			DoFunc:   "vol-start.Commit",
 			UndoFunc: "vol-start.Undo",
@@ -125,22 +157,50 @@ func volumeStartHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	txn.Ctx.Set("volname", volname)
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
+	if restutils.IsAsyncRequest(r) {
+		job, err := txn.DoAsync(func(_ transaction.TxnCtx, err error) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+			return markVolumeStarted(vol)
+		})
+		if err != nil {
+			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+			return
+		}
+		restutils.SendHTTPJobResponse(ctx, w, job)
+		return
+	}
+
 	_, e = txn.Do()
 	if e != nil {
 		logger.WithFields(log.Fields{
 			"error":  e.Error(),
 			"volume": volname,
 		}).Error("failed to start volume")
-		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, e.Error(), api.ErrCodeDefault)
+		restutils.SendHTTPTxnError(ctx, w, e)
 		return
 	}
 
-	vol.State = volume.VolStarted
-
-	e = volume.AddOrUpdateVolumeFunc(vol)
-	if e != nil {
+	if _, e = markVolumeStarted(vol); e != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, e.Error(), api.ErrCodeDefault)
 		return
 	}
 	restutils.SendHTTPResponse(ctx, w, http.StatusOK, vol)
 }
+
+func markVolumeStarted(vol *volume.Volinfo) (*volume.Volinfo, error) {
+	vol.State = volume.VolStarted
+
+	if err := volume.AddOrUpdateVolumeFunc(vol); err != nil {
+		return nil, err
+	}
+	return vol, nil
+}
@@ -109,14 +109,23 @@ func volumeStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txn := transaction.NewTxn(ctx)
+	txn := transaction.NewTxnWithInlineCtx(ctx)
 	defer txn.Cleanup()
+	// Status is read-only, so status requests for the same volume can run
+	// concurrently
+	lock, unlock, err := transaction.CreateSharedLockSteps(transaction.VolumeLockKey(volname))
+	if err != nil {
+		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		return
+	}
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
+		lock,
 		{
 			DoFunc: "vol-status.Check",
 			Nodes:  txn.Nodes,
 		},
+		unlock,
 	}
 
 	txn.Ctx.Set("volname", volname)
@@ -127,7 +136,7 @@ func volumeStatusHandler(w http.ResponseWriter, r *http.Request) {
 			"error":  err.Error(),
 			"volume": volname,
 		}).Error("volumeStatusHandler: Failed to get volume status.")
-		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
+		restutils.SendHTTPTxnError(ctx, w, err)
 		return
 	}
 
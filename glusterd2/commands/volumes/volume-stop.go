@@ -78,7 +78,21 @@ func stopBricks(c transaction.TxnCtx) error {
 	return nil
 }
 
+// validateVolumeStop checks, with the volume locked, that the volume isn't
+// stopped already
+func validateVolumeStop(c transaction.TxnCtx) error {
+	vol, err := getVolumeFromCtx(c)
+	if err != nil {
+		return err
+	}
+	if vol.State == volume.VolStopped {
+		return errors.ErrVolAlreadyStopped
+	}
+	return nil
+}
+
 func registerVolStopStepFuncs() {
+	transaction.RegisterStepFunc(validateVolumeStop, "vol-stop.Validate")
 	transaction.RegisterStepFunc(stopBricks, "vol-stop.Commit")
 }
 
@@ -101,7 +115,7 @@ func volumeStopHandler(w http.ResponseWriter, r *http.Request) {
 	// A simple one-step transaction to stop brick processes
 	txn := transaction.NewTxn(ctx)
 	defer txn.Cleanup()
-	lock, unlock, err := transaction.CreateLockSteps(volname)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(volname))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -109,6 +123,11 @@ func volumeStopHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "vol-stop.Validate",
+			Nodes:    []uuid.UUID{gdctx.MyUUID},
+			Validate: true,
+		},
 		{
 			DoFunc: "vol-stop.Commit",
 			Nodes:  txn.Nodes,
@@ -117,14 +136,17 @@ func volumeStopHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	txn.Ctx.Set("volname", volname)
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, err := txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, err)
+		return
+	}
+
 	if _, err = txn.Do(); err != nil {
 		logger.WithError(err).WithField(
 			"volume", volname).Error("failed to stop volume")
-		if err == transaction.ErrLockTimeout {
-			restutils.SendHTTPError(ctx, w, http.StatusConflict, err.Error(), api.ErrCodeDefault)
-		} else {
-			restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
-		}
+		restutils.SendHTTPTxnError(ctx, w, err)
 		return
 	}
 
@@ -57,10 +57,15 @@ func parseFlags() {
 	flag.String("clientaddress", defaultClientAddress, "Address to bind the REST service.")
 	flag.String("peeraddress", defaultPeerAddress, "Address to bind the inter glusterd2 RPC service.")
 
-	// TODO: SSL/TLS is currently only implemented for REST interface
 	flag.String("cert-file", "", "Certificate used for SSL/TLS connections from clients to glusterd2.")
 	flag.String("key-file", "", "Private key for the SSL/TLS certificate.")
 
+	flag.String("peer-cert-file", "", "Certificate used for mutual TLS connections between glusterd2 peers. It must be valid for the peer addresses.")
+	flag.String("peer-key-file", "", "Private key for the peer TLS certificate.")
+	flag.String("peer-ca-file", "", "CA certificate used to verify the certificates of glusterd2 peers.")
+
+	flag.String("restore", "", "Restore the cluster state from the given backup archive into a fresh store before starting.")
+
 	store.InitFlags()
 	volgen.InitFlags()
 
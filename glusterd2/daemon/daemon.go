@@ -50,6 +50,8 @@ type Daemon interface {
 // is already running, errors.ErrProcessAlreadyRunning is returned.
 // When wait == true, this function can be used to spawn short term processes
 // which will be waited on for completion before this function returns.
+// Daemons which exit without being stopped are reported with daemon-exited
+// events.
 func Start(d Daemon, wait bool) error {
 
 	log.WithFields(log.Fields{
@@ -61,9 +63,12 @@ func Start(d Daemon, wait bool) error {
 	// Check if pidfile exists
 	pid, err := ReadPidFromFile(d.PidFile())
 	if err == nil {
-		// Check if process is running
+		// Check if process is running, like daemons which kept
+		// running while GlusterD restarted. These are monitored from
+		// now on.
 		_, err := GetProcess(pid)
 		if err == nil {
+			monitorPid(d, pid)
 			return errors.ErrProcessAlreadyRunning
 		}
 	}
@@ -105,9 +110,14 @@ func Start(d Daemon, wait bool) error {
 			"pid":  pid,
 		}).Debug("Started daemon successfully")
 
+		// The daemon runs detached from the child, so it is monitored
+		// through its pid
+		monitorPid(d, pid)
+
 	} else {
 		// If the process exits at some point later, do read it's
 		// exit status. This should not let it be a zombie.
+		stop := monitor(d)
 		go func() {
 			err := cmd.Wait()
 			log.WithFields(log.Fields{
@@ -115,6 +125,8 @@ func Start(d Daemon, wait bool) error {
 				"pid":    cmd.Process.Pid,
 				"status": err,
 			}).Debug("Child exited.")
+
+			exited(d, stop, err)
 		}()
 	}
 
@@ -132,6 +144,9 @@ func Start(d Daemon, wait bool) error {
 // When force == true, a SIGKILL signal is sent to the daemon.
 func Stop(d Daemon, force bool) error {
 
+	// The daemon exiting is expected from now on
+	unmonitor(d)
+
 	// It is assumed that the process d has written to pidfile
 	pid, err := ReadPidFromFile(d.PidFile())
 	if err != nil {
@@ -174,6 +189,30 @@ func Stop(d Daemon, force bool) error {
 	return nil
 }
 
+// CountRunning returns the number of daemons with the given name, started on
+// this node, whose processes are running
+func CountRunning(name string) (int, error) {
+	ds, err := getDaemons()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, d := range ds {
+		if d.Name() != name {
+			continue
+		}
+		pid, err := ReadPidFromFile(d.PidFile())
+		if err != nil {
+			continue
+		}
+		if _, err := GetProcess(pid); err == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
 // StartAllDaemons starts all previously running daemons when GlusterD restarts
 func StartAllDaemons() {
 	log.Debug("starting all daemons")
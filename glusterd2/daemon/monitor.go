@@ -0,0 +1,113 @@
+package daemon
+
+// This file implements noticing daemons which exit without being stopped, like
+// bricks which crash. Most daemons, like bricks, detach from the process
+// started by Start(), so their processes are found from their pidfiles and
+// checked periodically.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// monitorInterval is the interval at which the processes of the
+	// daemons are checked
+	monitorInterval = 5 * time.Second
+
+	// notifyFunc sends the events of daemons exiting
+	notifyFunc = webhook.Notify
+
+	// monitored maps the IDs of the monitored daemons to channels closed
+	// to stop their monitoring
+	monitored = struct {
+		sync.Mutex
+		daemons map[string]chan struct{}
+	}{daemons: make(map[string]chan struct{})}
+)
+
+// monitor starts monitoring the daemon, replacing any previous monitoring of
+// it. The returned channel is closed when the monitoring is stopped.
+func monitor(d Daemon) chan struct{} {
+	stop := make(chan struct{})
+
+	monitored.Lock()
+	if old, ok := monitored.daemons[d.ID()]; ok {
+		close(old)
+	}
+	monitored.daemons[d.ID()] = stop
+	monitored.Unlock()
+
+	return stop
+}
+
+// monitorPid monitors the daemon with the process of the given pid, which
+// isn't a child of GlusterD
+func monitorPid(d Daemon, pid int) {
+	stop := monitor(d)
+
+	go func() {
+		ticker := time.NewTicker(monitorInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := GetProcess(pid); err == nil {
+					continue
+				}
+				exited(d, stop, nil)
+				return
+			}
+		}
+	}()
+}
+
+// unmonitor stops monitoring the daemon, as it is being stopped
+func unmonitor(d Daemon) {
+	monitored.Lock()
+	defer monitored.Unlock()
+
+	if stop, ok := monitored.daemons[d.ID()]; ok {
+		close(stop)
+		delete(monitored.daemons, d.ID())
+	}
+}
+
+// exited sends the daemon-exited event for the daemon, whose process exited
+// with the given error, unless the daemon was stopped or started again since
+// stop was returned by monitor()
+func exited(d Daemon, stop chan struct{}, err error) {
+	monitored.Lock()
+	if monitored.daemons[d.ID()] != stop {
+		monitored.Unlock()
+		return
+	}
+	close(stop)
+	delete(monitored.daemons, d.ID())
+	monitored.Unlock()
+
+	logger := log.WithField("name", d.Name())
+	if err != nil {
+		logger = logger.WithError(err)
+	}
+	logger.Warn("daemon exited without being stopped")
+
+	e := api.Event{
+		Type:   api.EventDaemonExited,
+		Daemon: d.Name(),
+		Peer:   gdctx.MyUUID.String(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	notifyFunc(e)
+}
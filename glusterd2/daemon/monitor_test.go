@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDaemon struct {
+	id string
+}
+
+func (d *testDaemon) Name() string       { return "test-daemon" }
+func (d *testDaemon) Path() string       { return "sleep" }
+func (d *testDaemon) Args() string       { return "60" }
+func (d *testDaemon) SocketFile() string { return "" }
+func (d *testDaemon) PidFile() string    { return "" }
+func (d *testDaemon) ID() string         { return d.id }
+
+// startTestProcess starts a process which is killed by the returned function
+func startTestProcess(t *testing.T) (int, func()) {
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skip("failed to start test process: ", err)
+	}
+	return cmd.Process.Pid, func() {
+		cmd.Process.Kill()
+		// Reap the process, so that it doesn't exist anymore
+		cmd.Wait()
+	}
+}
+
+// TestMonitorPid validates that daemons exiting without being stopped are
+// reported, and that stopped daemons aren't
+func TestMonitorPid(t *testing.T) {
+	defer func(d time.Duration) {
+		monitorInterval = d
+	}(monitorInterval)
+	monitorInterval = 10 * time.Millisecond
+
+	events := make(chan api.Event, 2)
+	defer testutils.Patch(&notifyFunc, func(e api.Event) {
+		events <- e
+	}).Restore()
+
+	d := &testDaemon{id: "test-monitor"}
+	pid, kill := startTestProcess(t)
+	monitorPid(d, pid)
+	kill()
+
+	select {
+	case e := <-events:
+		assert.Equal(t, api.EventDaemonExited, e.Type)
+		assert.Equal(t, d.Name(), e.Daemon)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for daemon-exited event")
+	}
+	assert.NotContains(t, monitored.daemons, d.ID())
+
+	// Daemons which are stopped aren't reported
+	pid, kill = startTestProcess(t)
+	monitorPid(d, pid)
+	unmonitor(d)
+	kill()
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event for stopped daemon: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.NotContains(t, monitored.daemons, d.ID())
+}
@@ -13,12 +13,14 @@ import (
 )
 
 const (
-	daemonsPrefix = store.GlusterPrefix + "daemons/"
+	// StorePrefix is the prefix under which the daemons to be run on each
+	// node are saved in the store, keyed by node ID and daemon ID
+	StorePrefix = store.GlusterPrefix + "daemons/"
 )
 
 // save saves the daemon information in the store
 func saveDaemon(d Daemon) error {
-	p := path.Join(daemonsPrefix, gdctx.MyUUID.String(), d.ID())
+	p := path.Join(StorePrefix, gdctx.MyUUID.String(), d.ID())
 
 	sd := newStoredDaemon(d)
 	data, err := json.Marshal(sd)
@@ -31,9 +33,12 @@ func saveDaemon(d Daemon) error {
 }
 
 // DelDaemon removes the daemon's entry from the store. This will ensure that
-// the daemon isn't restarted during glusterd2's restart.
+// the daemon isn't restarted during glusterd2's restart. The daemon exiting is
+// expected from then on, so it is no longer monitored.
 func DelDaemon(d Daemon) error {
-	p := path.Join(daemonsPrefix, gdctx.MyUUID.String(), d.ID())
+	unmonitor(d)
+
+	p := path.Join(StorePrefix, gdctx.MyUUID.String(), d.ID())
 
 	_, err := store.Store.Delete(context.TODO(), p)
 
@@ -41,7 +46,7 @@ func DelDaemon(d Daemon) error {
 }
 
 func getDaemon(id string) (Daemon, error) {
-	p := path.Join(daemonsPrefix, gdctx.MyUUID.String(), id)
+	p := path.Join(StorePrefix, gdctx.MyUUID.String(), id)
 
 	resp, err := store.Store.Get(context.TODO(), p)
 	if err != nil {
@@ -56,7 +61,7 @@ func getDaemon(id string) (Daemon, error) {
 }
 
 func getDaemons() ([]Daemon, error) {
-	p := path.Join(daemonsPrefix, gdctx.MyUUID.String())
+	p := path.Join(StorePrefix, gdctx.MyUUID.String())
 
 	resp, err := store.Store.Get(context.TODO(), p, clientv3.WithPrefix())
 	if err != nil {
@@ -76,6 +81,16 @@ func getDaemons() ([]Daemon, error) {
 	return ds, nil
 }
 
+// ParseStoredDaemon returns the name and the ID of the daemon saved in the
+// store with the given value
+func ParseStoredDaemon(data []byte) (string, string, error) {
+	sd, err := unmarshalStoredDaemon(data)
+	if err != nil {
+		return "", "", err
+	}
+	return sd.DName, sd.DID, nil
+}
+
 func unmarshalStoredDaemon(data []byte) (*storedDaemon, error) {
 	var sd storedDaemon
 	if err := json.Unmarshal(data, &sd); err != nil {
@@ -0,0 +1,140 @@
+// Package events implements a stream of the changes of the cluster state,
+// like volumes being created or bricks going offline. The stream is built on
+// a watch of the store, so every event carries the store revision of the
+// change, which can be used to resume the stream after a disconnect.
+package events
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gluster/glusterd2/glusterd2/daemon"
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+)
+
+// brickDaemonName is the name of the brick daemons saved in the store
+const brickDaemonName = "glusterfsd"
+
+// translate returns the events for a change of a key in the store
+func translate(ev *clientv3.Event) []api.Event {
+	key := string(ev.Kv.Key)
+
+	switch {
+	case strings.HasPrefix(key, volume.StorePrefix):
+		return volumeEvents(strings.TrimPrefix(key, volume.StorePrefix), ev)
+	case strings.HasPrefix(key, peer.StorePrefix):
+		return peerEvents(strings.TrimPrefix(key, peer.StorePrefix), ev)
+	case strings.HasPrefix(key, daemon.StorePrefix):
+		return brickEvents(strings.TrimPrefix(key, daemon.StorePrefix), ev)
+	}
+	return nil
+}
+
+func volumeEvents(name string, ev *clientv3.Event) []api.Event {
+	rev := ev.Kv.ModRevision
+
+	if ev.Type == clientv3.EventTypeDelete {
+		return []api.Event{{Revision: rev, Type: api.EventVolumeDeleted, Volume: name}}
+	}
+
+	var vol volume.Volinfo
+	if err := json.Unmarshal(ev.Kv.Value, &vol); err != nil {
+		log.WithError(err).WithField("volume", name).Error("failed to unmarshal volume")
+		return nil
+	}
+	if ev.IsCreate() {
+		return []api.Event{{Revision: rev, Type: api.EventVolumeCreated, Volume: name}}
+	}
+
+	// The changes are found by comparing the volume with its previous
+	// version, which is only missing if the watch was started at an old
+	// revision which has been compacted since
+	if ev.PrevKv == nil {
+		return nil
+	}
+	var prev volume.Volinfo
+	if err := json.Unmarshal(ev.PrevKv.Value, &prev); err != nil {
+		log.WithError(err).WithField("volume", name).Error("failed to unmarshal volume")
+		return nil
+	}
+
+	var events []api.Event
+	if vol.State != prev.State {
+		switch vol.State {
+		case volume.VolStarted:
+			events = append(events, api.Event{Revision: rev, Type: api.EventVolumeStarted, Volume: name})
+		case volume.VolStopped:
+			events = append(events, api.Event{Revision: rev, Type: api.EventVolumeStopped, Volume: name})
+		}
+	}
+
+	changed := make(map[string]string)
+	for k, v := range vol.Options {
+		if pv, ok := prev.Options[k]; !ok || pv != v {
+			changed[k] = v
+		}
+	}
+	for k := range prev.Options {
+		if _, ok := vol.Options[k]; !ok {
+			changed[k] = ""
+		}
+	}
+	if len(changed) != 0 {
+		events = append(events, api.Event{Revision: rev, Type: api.EventVolumeOptionChanged, Volume: name, Options: changed})
+	}
+
+	return events
+}
+
+func peerEvents(id string, ev *clientv3.Event) []api.Event {
+	rev := ev.Kv.ModRevision
+
+	switch {
+	case ev.Type == clientv3.EventTypeDelete:
+		return []api.Event{{Revision: rev, Type: api.EventPeerRemoved, Peer: id}}
+	case ev.IsCreate():
+		return []api.Event{{Revision: rev, Type: api.EventPeerAdded, Peer: id}}
+	}
+	return nil
+}
+
+// brickEvents returns the events for a change of a daemon saved in the
+// store. Brick daemons are saved when they are started and deleted when they
+// are stopped.
+func brickEvents(key string, ev *clientv3.Event) []api.Event {
+	// The key is <node ID>/<daemon ID>
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	typ := api.EventBrickOnline
+	value := ev.Kv.Value
+	if ev.Type == clientv3.EventTypeDelete {
+		if ev.PrevKv == nil {
+			return nil
+		}
+		typ = api.EventBrickOffline
+		value = ev.PrevKv.Value
+	} else if !ev.IsCreate() {
+		// The daemon was saved again when restarted by GlusterD, and was
+		// already online
+		return nil
+	}
+
+	name, id, err := daemon.ParseStoredDaemon(value)
+	if err != nil {
+		log.WithError(err).WithField("key", key).Error("failed to unmarshal daemon")
+		return nil
+	}
+	if name != brickDaemonName {
+		return nil
+	}
+
+	return []api.Event{{Revision: ev.Kv.ModRevision, Type: typ, Peer: parts[0], Brick: id}}
+}
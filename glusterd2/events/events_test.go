@@ -0,0 +1,88 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/daemon"
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func kv(t *testing.T, key string, v interface{}, createRev, modRev int64) *mvccpb.KeyValue {
+	b, err := json.Marshal(v)
+	assert.Nil(t, err)
+	return &mvccpb.KeyValue{Key: []byte(key), Value: b, CreateRevision: createRev, ModRevision: modRev}
+}
+
+// TestVolumeEvents validates the events for changes of volumes
+func TestVolumeEvents(t *testing.T) {
+	key := volume.StorePrefix + "vol1"
+	created := &volume.Volinfo{Name: "vol1", State: volume.VolCreated, Options: map[string]string{"afr.eager-lock": "on"}}
+	started := &volume.Volinfo{Name: "vol1", State: volume.VolStarted, Options: map[string]string{"io-stats.count-fop-hits": "on"}}
+
+	evs := translate(&clientv3.Event{Type: clientv3.EventTypePut, Kv: kv(t, key, created, 5, 5)})
+	assert.Equal(t, []api.Event{{Revision: 5, Type: api.EventVolumeCreated, Volume: "vol1"}}, evs)
+
+	evs = translate(&clientv3.Event{
+		Type:   clientv3.EventTypePut,
+		Kv:     kv(t, key, started, 5, 8),
+		PrevKv: kv(t, key, created, 5, 5),
+	})
+	assert.Equal(t, []api.Event{
+		{Revision: 8, Type: api.EventVolumeStarted, Volume: "vol1"},
+		{Revision: 8, Type: api.EventVolumeOptionChanged, Volume: "vol1", Options: map[string]string{
+			"afr.eager-lock":          "",
+			"io-stats.count-fop-hits": "on",
+		}},
+	}, evs)
+
+	// Saving an unchanged volume isn't an event
+	evs = translate(&clientv3.Event{
+		Type:   clientv3.EventTypePut,
+		Kv:     kv(t, key, started, 5, 9),
+		PrevKv: kv(t, key, started, 5, 8),
+	})
+	assert.Empty(t, evs)
+
+	evs = translate(&clientv3.Event{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: 10}})
+	assert.Equal(t, []api.Event{{Revision: 10, Type: api.EventVolumeDeleted, Volume: "vol1"}}, evs)
+}
+
+// TestPeerAndBrickEvents validates the events for changes of peers and brick
+// daemons
+func TestPeerAndBrickEvents(t *testing.T) {
+	id := uuid.NewRandom()
+	p := &peer.Peer{ID: id, Name: "node1"}
+
+	evs := translate(&clientv3.Event{Type: clientv3.EventTypePut, Kv: kv(t, peer.StorePrefix+id.String(), p, 3, 3)})
+	assert.Equal(t, []api.Event{{Revision: 3, Type: api.EventPeerAdded, Peer: id.String()}}, evs)
+	evs = translate(&clientv3.Event{Type: clientv3.EventTypePut, Kv: kv(t, peer.StorePrefix+id.String(), p, 3, 4)})
+	assert.Empty(t, evs)
+
+	data, err := json.Marshal(struct {
+		DName, DID string
+	}{brickDaemonName, "/bricks/b1"})
+	assert.Nil(t, err)
+	key := daemon.StorePrefix + id.String() + "/bricks/b1"
+
+	online := &mvccpb.KeyValue{Key: []byte(key), Value: data, CreateRevision: 6, ModRevision: 6}
+	evs = translate(&clientv3.Event{Type: clientv3.EventTypePut, Kv: online})
+	assert.Equal(t, []api.Event{{Revision: 6, Type: api.EventBrickOnline, Peer: id.String(), Brick: "/bricks/b1"}}, evs)
+
+	evs = translate(&clientv3.Event{
+		Type:   clientv3.EventTypeDelete,
+		Kv:     &mvccpb.KeyValue{Key: []byte(key), ModRevision: 7},
+		PrevKv: online,
+	})
+	assert.Equal(t, []api.Event{{Revision: 7, Type: api.EventBrickOffline, Peer: id.String(), Brick: "/bricks/b1"}}, evs)
+
+	// Changes of other keys aren't events
+	assert.Empty(t, translate(&clientv3.Event{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("gluster/locks/cluster")}}))
+}
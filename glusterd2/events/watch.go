@@ -0,0 +1,137 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/gluster/glusterd2/glusterd2/daemon"
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+var (
+	// ErrCompacted is returned if the events after the requested revision
+	// are no longer available in the store
+	ErrCompacted = errors.New("events after the requested revision are no longer available")
+	// ErrFutureRevision is returned if the requested revision is not yet
+	// reached by the store
+	ErrFutureRevision = errors.New("requested revision is higher than the current store revision")
+)
+
+// watchPrefixes are the prefixes of the keys whose changes are events. All the
+// keys are watched with a single watch, so that the changes are received in
+// the order of their revisions, and the changes of other keys, like locks and
+// transaction journals, are dropped.
+var watchPrefixes = []string{volume.StorePrefix, peer.StorePrefix, daemon.StorePrefix}
+
+// watched returns true if the changes of the key are events
+func watched(key []byte) bool {
+	for _, prefix := range watchPrefixes {
+		if bytes.HasPrefix(key, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stream is a stream of events
+type Stream struct {
+	events chan api.Event
+	err    error
+}
+
+// Watch returns a stream of the events which happen after the given store
+// revision, or from now if the revision is 0. The stream ends when ctx is
+// cancelled or when the watch of the store fails.
+func Watch(ctx context.Context, afterRev int64) (*Stream, error) {
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithPrevKV()}
+
+	if afterRev > 0 {
+		// Check that the events after the revision can still be
+		// watched before starting the stream, to be able to report it
+		// to the client
+		_, err := store.Store.Get(ctx, store.GlusterPrefix, clientv3.WithRev(afterRev), clientv3.WithCountOnly())
+		if err != nil {
+			return nil, storeErr(err)
+		}
+		opts = append(opts, clientv3.WithRev(afterRev+1))
+	} else {
+		// The watch is only created once the stream is returned, so it
+		// starts from the current revision to not miss the changes
+		// made in between
+		resp, err := store.Store.Get(ctx, store.GlusterPrefix, clientv3.WithCountOnly())
+		if err != nil {
+			return nil, storeErr(err)
+		}
+		opts = append(opts, clientv3.WithRev(resp.Header.Revision+1))
+	}
+
+	// The watch is cancelled when the stream ends. WithRequireLeader ends
+	// the watch if the local store member is partitioned from the rest of
+	// the store, instead of silently waiting for events which won't be
+	// received.
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	wch := store.Store.Watch(wctx, store.GlusterPrefix, opts...)
+
+	s := &Stream{events: make(chan api.Event)}
+	go func() {
+		defer cancel()
+		s.run(ctx, wch)
+	}()
+	return s, nil
+}
+
+// Events returns the channel on which the events are sent. It is closed when
+// the stream ends.
+func (s *Stream) Events() <-chan api.Event {
+	return s.events
+}
+
+// Err returns the reason the stream ended. It must only be called once the
+// events channel is closed.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+func (s *Stream) run(ctx context.Context, wch clientv3.WatchChan) {
+	defer close(s.events)
+
+	for wresp := range wch {
+		if err := wresp.Err(); err != nil {
+			s.err = storeErr(err)
+			return
+		}
+		for _, ev := range wresp.Events {
+			if !watched(ev.Kv.Key) {
+				continue
+			}
+			for _, e := range translate(ev) {
+				select {
+				case s.events <- e:
+				case <-ctx.Done():
+					s.err = ctx.Err()
+					return
+				}
+			}
+		}
+	}
+
+	// The watch channel is closed when ctx is cancelled
+	s.err = ctx.Err()
+}
+
+func storeErr(err error) error {
+	switch err {
+	case rpctypes.ErrCompacted:
+		return ErrCompacted
+	case rpctypes.ErrFutureRev:
+		return ErrFutureRevision
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/daemon"
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// initTestStore starts an embedded store for the tests which need one
+func initTestStore(t *testing.T) func() {
+	if testing.Short() {
+		t.Skip("skipping embedded store test in short mode")
+	}
+
+	gdctx.MyUUID = uuid.NewRandom()
+	dir, err := ioutil.TempDir("", "gd2events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	destroy, err := store.InitTestStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return func() {
+		destroy()
+		os.RemoveAll(dir)
+	}
+}
+
+func put(t *testing.T, key string, v interface{}) int64 {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := store.Store.Put(context.Background(), key, string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Header.Revision
+}
+
+// receive returns the next n events of the stream
+func receive(t *testing.T, s *Stream, n int) []api.Event {
+	var evs []api.Event
+	for len(evs) < n {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				t.Fatalf("stream ended: %v", s.Err())
+			}
+			evs = append(evs, e)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for events, received %v", evs)
+		}
+	}
+	return evs
+}
+
+// TestWatch validates that only the changes of the watched prefixes are
+// streamed, in the order of their revisions, including when resuming a stream
+func TestWatch(t *testing.T) {
+	defer initTestStore(t)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := Watch(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := uuid.NewRandom()
+	brick, err := json.Marshal(struct {
+		DName, DID string
+	}{brickDaemonName, "/bricks/b1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes of other keys, like locks, aren't watched
+	if _, err := store.Store.Put(context.Background(), store.GlusterPrefix+"locks/cluster", ""); err != nil {
+		t.Fatal(err)
+	}
+	volRev := put(t, volume.StorePrefix+"vol1", &volume.Volinfo{Name: "vol1"})
+	peerRev := put(t, peer.StorePrefix+id.String(), &peer.Peer{ID: id})
+	resp, err := store.Store.Put(context.Background(), daemon.StorePrefix+id.String()+"/bricks/b1", string(brick))
+	if err != nil {
+		t.Fatal(err)
+	}
+	brickRev := resp.Header.Revision
+
+	expected := []api.Event{
+		{Revision: volRev, Type: api.EventVolumeCreated, Volume: "vol1"},
+		{Revision: peerRev, Type: api.EventPeerAdded, Peer: id.String()},
+		{Revision: brickRev, Type: api.EventBrickOnline, Peer: id.String(), Brick: "/bricks/b1"},
+	}
+	assert.Equal(t, expected, receive(t, s, 3))
+
+	// A resumed stream catches up with the changes missed, in order
+	resumed, err := Watch(ctx, volRev-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, receive(t, resumed, 3))
+
+	cancel()
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+	assert.Equal(t, context.Canceled, s.Err())
+}
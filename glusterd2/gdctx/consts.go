@@ -5,3 +5,6 @@ const ReqIDKey = "req-id"
 
 // ReqLoggerKey is the name of the key to set/get request-scoped logger in context.Context
 const ReqLoggerKey = "req-logger"
+
+// ReqUserKey is the name of the key to set/get the authenticated user of a request in context.Context
+const ReqUserKey = "req-user"
@@ -0,0 +1,232 @@
+// Package health implements the health records of nodes. Every node
+// periodically publishes a health record into the store, along with its
+// liveness, which is used to report the status of the node and the reason it
+// can't take part in transactions.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/daemon"
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/version"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// healthPrefix is the prefix of the last health records of the nodes,
+	// which are kept after the nodes go down
+	healthPrefix = store.GlusterPrefix + "health/"
+
+	brickDaemonName = "glusterfsd"
+)
+
+var (
+	// heartbeatInterval is the interval at which health records are
+	// published
+	heartbeatInterval = 10 * time.Second
+	// staleAfter is the time after which an alive node whose liveness key
+	// isn't seen changing is reported as unresponsive
+	staleAfter = 3 * heartbeatInterval
+
+	started = time.Now()
+
+	// now returns the current time of this node. Only durations between
+	// the times it returns are used, never the times published by the
+	// other nodes, whose clocks may differ.
+	now = time.Now
+
+	// changes are the last changes of the liveness keys of the alive nodes,
+	// as seen by this node
+	changes = struct {
+		sync.Mutex
+		nodes map[string]change
+	}{nodes: make(map[string]change)}
+)
+
+// change is a change of the liveness key of a node, published with the
+// health record of the node
+type change struct {
+	revision int64
+	seen     time.Time
+}
+
+// sinceChange returns the time since this node first saw the liveness key of
+// the given node at the given revision. The first time a node is seen, its
+// liveness key is assumed to have just changed.
+func sinceChange(nodeID string, revision int64) time.Duration {
+	changes.Lock()
+	defer changes.Unlock()
+
+	c, ok := changes.nodes[nodeID]
+	if !ok || c.revision != revision {
+		c = change{revision, now()}
+		changes.nodes[nodeID] = c
+	}
+	return now().Sub(c.seen)
+}
+
+// forgetChange forgets the last change of the liveness key of a node which is
+// down
+func forgetChange(nodeID string) {
+	changes.Lock()
+	delete(changes.nodes, nodeID)
+	changes.Unlock()
+}
+
+// record returns the current health record of this node
+func record(storeLatency time.Duration) *api.NodeHealth {
+	h := &api.NodeHealth{
+		ID:              gdctx.MyUUID,
+		LastHeartbeat:   time.Now().UTC(),
+		Uptime:          time.Since(started),
+		GlusterdVersion: version.GlusterdVersion,
+		OpVersion:       gdctx.OpVersion,
+		StoreLatency:    storeLatency,
+	}
+
+	var info unix.Sysinfo_t
+	if err := unix.Sysinfo(&info); err == nil {
+		// The load averages are fixed point numbers with 16 bits
+		// of fraction
+		h.Load = float64(info.Loads[0]) / (1 << 16)
+	}
+
+	if n, err := daemon.CountRunning(brickDaemonName); err == nil {
+		h.RunningBricks = n
+	}
+
+	return h
+}
+
+// publish publishes the health record with the liveness of this node, and
+// keeps a copy of it to be reported after this node goes down. It returns the
+// time taken to publish the record.
+func publish(h *api.NodeHealth) (time.Duration, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := store.Store.PublishLiveness(string(b)); err != nil {
+		return 0, err
+	}
+	_, err = store.Store.Put(context.TODO(), healthPrefix+h.ID.String(), string(b))
+	return time.Since(start), err
+}
+
+// Publish publishes the health record of this node periodically, until ctx
+// is cancelled
+func Publish(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	var latency time.Duration
+	for {
+		l, err := publish(record(latency))
+		if err != nil {
+			log.WithError(err).Warn("failed to publish health record")
+		} else {
+			latency = l
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Get returns the health of the given node
+func Get(nodeID uuid.UUID) (*api.NodeHealth, error) {
+	h, _, err := get(nodeID)
+	return h, err
+}
+
+// get returns the health of the given node, and the time since its liveness
+// key was last seen changing
+func get(nodeID uuid.UUID) (*api.NodeHealth, time.Duration, error) {
+	value, revision, alive, err := store.Store.GetLiveness(nodeID.String())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The liveness key of a node which just started has no health record
+	// yet, while the last health record of a node which is down is kept
+	// separately
+	var unchanged time.Duration
+	if alive {
+		unchanged = sinceChange(nodeID.String(), revision)
+	} else {
+		forgetChange(nodeID.String())
+		resp, err := store.Store.Get(context.TODO(), healthPrefix+nodeID.String())
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.Count == 1 {
+			value = resp.Kvs[0].Value
+		}
+	}
+
+	h := &api.NodeHealth{ID: nodeID}
+	if len(value) != 0 {
+		if err := json.Unmarshal(value, h); err != nil {
+			return nil, 0, err
+		}
+	}
+	h.Status = status(alive, unchanged)
+	return h, unchanged, nil
+}
+
+// status returns the status of a node, given whether it is alive and the time
+// since its liveness key was last seen changing. Alive nodes publish their
+// health records with their liveness keys periodically, so the keys of
+// responsive nodes keep changing.
+func status(alive bool, unchanged time.Duration) api.HealthStatus {
+	switch {
+	case !alive:
+		return api.HealthOffline
+	case unchanged > staleAfter:
+		return api.HealthUnresponsive
+	}
+	return api.HealthOnline
+}
+
+// Check returns an error describing why the given node can't take part in a
+// transaction, or nil if it is online
+func Check(nodeID uuid.UUID) error {
+	h, unchanged, err := get(nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to get health of node %s: %s", nodeID, err)
+	}
+
+	switch h.Status {
+	case api.HealthOffline:
+		lastSeen := "it never published its health"
+		if !h.LastHeartbeat.IsZero() {
+			lastSeen = "last heartbeat at " + h.LastHeartbeat.Format(time.RFC3339)
+		}
+		return fmt.Errorf("node %s is offline, its store session has expired (%s)", nodeID, lastSeen)
+	case api.HealthUnresponsive:
+		return fmt.Errorf("node %s is unresponsive, no heartbeat seen for %s", nodeID, unchanged/time.Second*time.Second)
+	}
+	return nil
+}
+
+// Delete deletes the health record of the given node, when it is removed
+// from the cluster
+func Delete(nodeID uuid.UUID) error {
+	_, err := store.Store.Delete(context.TODO(), healthPrefix+nodeID.String())
+	return err
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStatus validates the status of nodes computed from the changes of
+// their liveness keys, as seen with the clock of this node only
+func TestStatus(t *testing.T) {
+	clock := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) {
+		now = f
+	}(now)
+	now = func() time.Time {
+		return clock
+	}
+
+	// Nodes are assumed to be responsive when first seen
+	assert.Equal(t, api.HealthOnline, status(true, sinceChange("node1", 5)))
+	clock = clock.Add(staleAfter / 2)
+	assert.Equal(t, api.HealthOnline, status(true, sinceChange("node1", 5)))
+
+	// Nodes whose liveness keys don't change become unresponsive, until
+	// their keys change again
+	clock = clock.Add(staleAfter)
+	assert.Equal(t, api.HealthUnresponsive, status(true, sinceChange("node1", 5)))
+	assert.Equal(t, api.HealthOnline, status(true, sinceChange("node1", 8)))
+
+	assert.Equal(t, api.HealthOffline, status(false, 0))
+	forgetChange("node1")
+	assert.NotContains(t, changes.nodes, "node1")
+}
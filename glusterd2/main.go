@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"path"
 	"strings"
 	"time"
 
+	"github.com/gluster/glusterd2/glusterd2/backup"
+	"github.com/gluster/glusterd2/glusterd2/cluster"
 	"github.com/gluster/glusterd2/glusterd2/daemon"
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/health"
+	"github.com/gluster/glusterd2/glusterd2/migration"
 	"github.com/gluster/glusterd2/glusterd2/peer"
 	"github.com/gluster/glusterd2/glusterd2/servers"
 	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
 	"github.com/gluster/glusterd2/glusterd2/volgen"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
 	"github.com/gluster/glusterd2/glusterd2/xlator"
 	"github.com/gluster/glusterd2/pkg/logging"
 	"github.com/gluster/glusterd2/pkg/utils"
@@ -87,10 +94,42 @@ func main() {
 		log.WithError(err).Fatal("Failed to initialize store (etcd client)")
 	}
 
+	// Re-seed the store from a backup, before adding our own details, which
+	// may already be in the backup
+	if restoreFile := config.GetString("restore"); restoreFile != "" {
+		if err := backup.RestoreFile(restoreFile); err != nil {
+			log.WithError(err).WithField("file", restoreFile).Fatal("Failed to restore store from backup")
+		}
+	}
+	if err := backup.CheckRestore(); err != nil {
+		log.WithError(err).Fatal("Failed to check store")
+	}
+
+	// Upgrade the layout of the stored objects before they are used
+	if err := migration.Run(); err != nil {
+		log.WithError(err).Fatal("Failed to migrate store")
+	}
+
 	if err := peer.AddSelfDetails(); err != nil {
 		log.WithError(err).Fatal("Could not add self details into etcd")
 	}
 
+	if err := cluster.InitOpVersion(); err != nil {
+		log.WithError(err).Fatal("Failed to initialize cluster op-version")
+	}
+
+	// Publish the health of this node with its liveness
+	go health.Publish(context.Background())
+
+	// Send events to the webhooks, and peer liveness changes with them.
+	// Deliveries still being retried are given up when GlusterD stops.
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	go webhook.Start(webhookCtx)
+	go webhook.WatchLiveness(webhookCtx)
+
+	// Keep the transaction history bounded
+	go transaction.PruneHistory(context.Background())
+
 	// If REST API Auth is enabled, Generate Auth file with random secret in workdir
 	if err := gdctx.GenerateLocalAuthToken(); err != nil {
 		log.WithError(err).Fatal("Failed to generate local auth token")
@@ -101,6 +140,10 @@ func main() {
 	super.ServeBackground()
 	super.Add(servers.New())
 
+	// Recover transactions left incomplete by a previous instance. This
+	// needs the StepFuncs, which are registered when the servers are created.
+	transaction.RecoverTransactions()
+
 	// Restart previously running daemons
 	daemon.StartAllDaemons()
 
@@ -115,6 +158,7 @@ func main() {
 		case unix.SIGINT:
 			log.Info("Received SIGTERM. Stopping GlusterD")
 			super.Stop()
+			stopWebhooks()
 			store.Close()
 			log.Info("Stopped GlusterD")
 			return
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -86,7 +87,10 @@ func Auth(next http.Handler) http.Handler {
 
 		// TODO: Filter URLs here if any role based control of APIs, this depends on User management feature
 
-		// Authentication is successful, continue serving the request
-		next.ServeHTTP(w, r)
+		// Authentication is successful, continue serving the request with
+		// the user set in the request context
+		claims := token.Claims.(jwt.MapClaims)
+		ctx := context.WithValue(r.Context(), gdctx.ReqUserKey, claims["iss"].(string))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,253 @@
+// Package migration implements upgrading the layout of the objects kept in
+// the store, like volumes and peers, when it changes between GlusterD
+// versions.
+//
+// The version of the layout, the schema version, is kept in the store. Every
+// change of the layout is a Migration, registered with the version it
+// upgrades the store to. At startup, the migrations newer than the schema
+// version of the store are run in order, and the schema version is raised.
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
+
+	"github.com/coreos/etcd/clientv3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	schemaVersionKey = store.GlusterPrefix + "schema-version"
+	// maxTxnOps is the number of keys written per store transaction, which
+	// is below the default limit of operations in a transaction of the
+	// store
+	maxTxnOps = 100
+)
+
+// Object is a stored object, as unmarshalled from its JSON value into a
+// generic map. Migrations work on generic objects, so that they don't depend
+// on the current layout of the Go types.
+type Object map[string]interface{}
+
+// Migration upgrades the objects stored under a prefix of the store.
+// Migrations must be idempotent, as a migration interrupted by a crash is run
+// again on all the objects. They should only make changes which GlusterD
+// versions using the previous layout can still read, like adding fields,
+// since peers are upgraded one at a time.
+type Migration struct {
+	// Version is the schema version the migration upgrades the store to.
+	// The versions of the registered migrations must be consecutive,
+	// starting from 1.
+	Version     int
+	Description string
+	// Prefix is the prefix of the keys of the objects to migrate
+	Prefix string
+	// Migrate upgrades an object in place. It returns true if the object
+	// was changed.
+	Migrate func(key string, o Object) (bool, error)
+}
+
+var registry []*Migration
+
+// Register registers a migration
+func Register(m *Migration) {
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool {
+		return registry[i].Version < registry[j].Version
+	})
+}
+
+// SchemaVersion returns the schema version of this GlusterD, which is the
+// version of the last registered migration
+func SchemaVersion() int {
+	if len(registry) == 0 {
+		return 0
+	}
+	return registry[len(registry)-1].Version
+}
+
+// validateRegistry checks that the versions of the registered migrations are
+// consecutive
+func validateRegistry() error {
+	for i, m := range registry {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expected %d", m.Description, m.Version, i+1)
+		}
+	}
+	return nil
+}
+
+// migrate runs the migration on the given key-value pairs, and returns the
+// changed ones
+func (m *Migration) migrate(kvs map[string][]byte) (map[string][]byte, error) {
+	changed := make(map[string][]byte)
+	for key, value := range kvs {
+		var o Object
+		if err := json.Unmarshal(value, &o); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %s", key, err)
+		}
+
+		ok, err := m.Migrate(key, o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s: %s", key, err)
+		}
+		if !ok {
+			continue
+		}
+
+		b, err := json.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		changed[key] = b
+	}
+	return changed, nil
+}
+
+// getSchemaVersion returns the schema version of the store, and whether it is
+// set
+func getSchemaVersion() (int, bool, error) {
+	resp, err := store.Store.Get(context.TODO(), schemaVersionKey)
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.Count == 0 {
+		return 0, false, nil
+	}
+
+	v, err := strconv.Atoi(string(resp.Kvs[0].Value))
+	return v, true, err
+}
+
+// schemaVersionCmp compares the schema version of the store to the given
+// version
+func schemaVersionCmp(v int) clientv3.Cmp {
+	if v == 0 {
+		return clientv3.Compare(clientv3.CreateRevision(schemaVersionKey), "=", 0)
+	}
+	return clientv3.Compare(clientv3.Value(schemaVersionKey), "=", strconv.Itoa(v))
+}
+
+// isFreshStore returns true if the store holds no cluster state yet. Keys
+// attached to leases, like liveness keys, are not cluster state.
+func isFreshStore() (bool, error) {
+	resp, err := store.Store.Get(context.TODO(), store.GlusterPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return false, err
+	}
+	for _, kv := range resp.Kvs {
+		if kv.Lease == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Run upgrades the store to the schema version of this GlusterD. It must be
+// called at startup, before the stored objects are used. An exclusive
+// cluster lock is held while migrating, so that peers starting at the same
+// time don't migrate concurrently.
+func Run() error {
+	if err := validateRegistry(); err != nil {
+		return err
+	}
+
+	unlock, err := transaction.Lock(context.Background(), transaction.ClusterLockKey, transaction.LockExclusive)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, ok, err := getSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fresh, err := isFreshStore()
+		if err != nil {
+			return err
+		}
+		if fresh {
+			// Objects are created with the current layout
+			_, err := store.Store.Put(context.TODO(), schemaVersionKey, strconv.Itoa(SchemaVersion()))
+			return err
+		}
+	}
+
+	if current > SchemaVersion() {
+		log.WithFields(log.Fields{
+			"store-version": current,
+			"our-version":   SchemaVersion(),
+		}).Warn("store schema is newer than this GlusterD, some stored objects may not be understood")
+		return nil
+	}
+
+	for _, m := range registry[current:] {
+		if err := run(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the migration on the store and raises the schema version to its
+// version
+func run(m *Migration) error {
+	logger := log.WithFields(log.Fields{
+		"version":   m.Version,
+		"migration": m.Description,
+	})
+	logger.Info("migrating store")
+
+	resp, err := store.Store.Get(context.TODO(), m.Prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	kvs := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if kv.Lease == 0 {
+			kvs[string(kv.Key)] = kv.Value
+		}
+	}
+
+	changed, err := m.migrate(kvs)
+	if err != nil {
+		return err
+	}
+
+	ops := make([]clientv3.Op, 0, len(changed)+1)
+	for key, value := range changed {
+		ops = append(ops, clientv3.OpPut(key, string(value)))
+	}
+	ops = append(ops, clientv3.OpPut(schemaVersionKey, strconv.Itoa(m.Version)))
+
+	// The objects are written in batches, the last one raising the schema
+	// version. Each batch only succeeds if the schema version is still the
+	// one the migration upgrades from.
+	for start := 0; start < len(ops); start += maxTxnOps {
+		end := start + maxTxnOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		tresp, err := store.Store.Txn(context.TODO()).
+			If(schemaVersionCmp(m.Version - 1)).
+			Then(ops[start:end]...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if !tresp.Succeeded {
+			return fmt.Errorf("store schema version changed while running migration %d", m.Version)
+		}
+	}
+
+	logger.WithField("objects", len(changed)).Info("migrated store")
+	return nil
+}
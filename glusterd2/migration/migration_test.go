@@ -0,0 +1,99 @@
+package migration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/version"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loadFixtures returns the objects stored in testdata/<layout>, as key-value
+// pairs of the store
+func loadFixtures(t *testing.T, layout string) map[string][]byte {
+	prefixes := map[string]string{
+		"volumes": volume.StorePrefix,
+		"peers":   peer.StorePrefix,
+	}
+
+	kvs := make(map[string][]byte)
+	for dir, prefix := range prefixes {
+		files, err := filepath.Glob(filepath.Join("testdata", layout, dir, "*.json"))
+		assert.Nil(t, err)
+		for _, f := range files {
+			b, err := ioutil.ReadFile(f)
+			assert.Nil(t, err)
+			kvs[prefix+strings.TrimSuffix(filepath.Base(f), ".json")] = b
+		}
+	}
+	return kvs
+}
+
+// migrateAll runs the registered migrations from the given schema version on
+// the key-value pairs
+func migrateAll(t *testing.T, kvs map[string][]byte, from int) {
+	for _, m := range registry[from:] {
+		selected := make(map[string][]byte)
+		for k, v := range kvs {
+			if strings.HasPrefix(k, m.Prefix) {
+				selected[k] = v
+			}
+		}
+
+		changed, err := m.migrate(selected)
+		assert.Nil(t, err)
+		for k, v := range changed {
+			kvs[k] = v
+		}
+	}
+}
+
+// TestRegistry validates the versions of the registered migrations
+func TestRegistry(t *testing.T) {
+	assert.Nil(t, validateRegistry())
+	assert.Equal(t, len(registry), SchemaVersion())
+}
+
+// TestMigrateFixtures validates that objects stored with older layouts are
+// migrated to the current layout
+func TestMigrateFixtures(t *testing.T) {
+	kvs := loadFixtures(t, "v0")
+	expected := loadFixtures(t, "v2")
+	assert.NotEmpty(t, kvs)
+	assert.Equal(t, len(expected), len(kvs))
+
+	migrateAll(t, kvs, 0)
+
+	for k, v := range expected {
+		var want, got interface{}
+		assert.Nil(t, json.Unmarshal(v, &want))
+		assert.Nil(t, json.Unmarshal(kvs[k], &got), k)
+		assert.Equal(t, want, got, k)
+	}
+
+	var vol volume.Volinfo
+	assert.Nil(t, json.Unmarshal(kvs[volume.StorePrefix+"vol1"], &vol))
+	assert.NotNil(t, vol.Options)
+	for _, b := range vol.Bricks {
+		assert.Equal(t, "vol1", b.VolumeName)
+		assert.Equal(t, vol.ID, b.VolumeID)
+	}
+
+	var p peer.Peer
+	assert.Nil(t, json.Unmarshal(kvs[peer.StorePrefix+"d0a7b0f4-2e0b-4c3e-9a4d-7b1f7e0c6a11"], &p))
+	assert.Equal(t, version.MinOpVersion, p.OpVersion)
+
+	// Migrations are idempotent
+	migrated := make(map[string][]byte)
+	for k, v := range kvs {
+		migrated[k] = v
+	}
+	migrateAll(t, kvs, 0)
+	assert.Equal(t, migrated, kvs)
+}
@@ -0,0 +1,72 @@
+package migration
+
+import (
+	"fmt"
+
+	"github.com/gluster/glusterd2/glusterd2/peer"
+	"github.com/gluster/glusterd2/glusterd2/volume"
+	"github.com/gluster/glusterd2/version"
+)
+
+// This file holds the migrations of the layouts of the stored objects. New
+// migrations are added at the end, with the next version.
+
+func init() {
+	Register(&Migration{
+		Version:     1,
+		Description: "initialize volume options and set the volume of bricks",
+		Prefix:      volume.StorePrefix,
+		Migrate:     migrateVolumeBricks,
+	})
+	Register(&Migration{
+		Version:     2,
+		Description: "set the op-versions supported by peers",
+		Prefix:      peer.StorePrefix,
+		Migrate:     migratePeerOpVersions,
+	})
+}
+
+// migrateVolumeBricks initializes the options of volumes stored without any,
+// which couldn't be set, and sets the volume name and ID of the bricks stored
+// without them
+func migrateVolumeBricks(key string, o Object) (bool, error) {
+	changed := false
+
+	if o["Options"] == nil {
+		o["Options"] = map[string]interface{}{}
+		changed = true
+	}
+
+	bricks, _ := o["Bricks"].([]interface{})
+	for _, b := range bricks {
+		brick, ok := b.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("invalid brick %v", b)
+		}
+		if name, _ := brick["VolumeName"].(string); name == "" {
+			brick["VolumeName"] = o["Name"]
+			changed = true
+		}
+		if id, _ := brick["VolumeID"].(string); id == "" {
+			brick["VolumeID"] = o["ID"]
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// migratePeerOpVersions sets the op-versions of peers stored before peers
+// published them, which only supported version.MinOpVersion
+func migratePeerOpVersions(key string, o Object) (bool, error) {
+	changed := false
+
+	for _, field := range []string{"OpVersion", "MinOpVersion"} {
+		if v, _ := o[field].(float64); v == 0 {
+			o[field] = version.MinOpVersion
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
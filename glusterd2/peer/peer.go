@@ -2,6 +2,8 @@
 package peer
 
 import (
+	"github.com/gluster/glusterd2/version"
+
 	"github.com/pborman/uuid"
 )
 
@@ -10,6 +12,26 @@ type Peer struct {
 	ID        uuid.UUID
 	Name      string
 	Addresses []string
+	// OpVersion is the highest op-version supported by the peer. It is 0
+	// for peers which do not publish it, which only support
+	// version.MinOpVersion.
+	OpVersion int
+	// MinOpVersion is the lowest op-version supported by the peer. It is 0
+	// for peers which do not publish it.
+	MinOpVersion int
+}
+
+// OpVersions returns the lowest and the highest op-versions supported by the
+// peer
+func (p *Peer) OpVersions() (int, int) {
+	min, max := p.MinOpVersion, p.OpVersion
+	if min == 0 {
+		min = version.MinOpVersion
+	}
+	if max == 0 {
+		max = version.MinOpVersion
+	}
+	return min, max
 }
 
 // ETCDConfig represents the structure which holds the ETCD env variables &
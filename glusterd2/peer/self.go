@@ -2,6 +2,7 @@ package peer
 
 import (
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/version"
 
 	config "github.com/spf13/viper"
 )
@@ -9,9 +10,11 @@ import (
 // AddSelfDetails results in the peer adding its own details into etcd
 func AddSelfDetails() error {
 	p := &Peer{
-		ID:        gdctx.MyUUID,
-		Name:      gdctx.HostName,
-		Addresses: []string{config.GetString("peeraddress")},
+		ID:           gdctx.MyUUID,
+		Name:         gdctx.HostName,
+		Addresses:    []string{config.GetString("peeraddress")},
+		OpVersion:    gdctx.OpVersion,
+		MinOpVersion: version.MinOpVersion,
 	}
 
 	return AddOrUpdatePeer(p)
@@ -17,7 +17,8 @@ import (
 )
 
 const (
-	peerPrefix string = store.GlusterPrefix + "peers/"
+	// StorePrefix is the prefix under which peers are saved in the store
+	StorePrefix string = store.GlusterPrefix + "peers/"
 )
 
 var (
@@ -42,7 +43,7 @@ func AddOrUpdatePeer(p *Peer) error {
 
 	idStr := p.ID.String()
 
-	if _, err := store.Store.Put(context.TODO(), peerPrefix+idStr, string(json)); err != nil {
+	if _, err := store.Store.Put(context.TODO(), StorePrefix+idStr, string(json)); err != nil {
 		return err
 	}
 
@@ -51,7 +52,7 @@ func AddOrUpdatePeer(p *Peer) error {
 
 // GetPeer returns specified peer from the store
 func GetPeer(id string) (*Peer, error) {
-	resp, err := store.Store.Get(context.TODO(), peerPrefix+id)
+	resp, err := store.Store.Get(context.TODO(), StorePrefix+id)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +90,7 @@ func GetInitialCluster() (string, error) {
 
 // GetPeers returns all available peers in the store
 func GetPeers() ([]*Peer, error) {
-	resp, err := store.Store.Get(context.TODO(), peerPrefix, clientv3.WithPrefix())
+	resp, err := store.Store.Get(context.TODO(), StorePrefix, clientv3.WithPrefix())
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +114,7 @@ func GetPeers() ([]*Peer, error) {
 
 // GetPeerIDs returns peer id (uuid) of all peers in the store
 func GetPeerIDs() ([]uuid.UUID, error) {
-	resp, err := store.Store.Get(context.TODO(), peerPrefix, clientv3.WithPrefix())
+	resp, err := store.Store.Get(context.TODO(), StorePrefix, clientv3.WithPrefix())
 	if err != nil {
 		return nil, err
 	}
@@ -152,13 +153,13 @@ func GetPeerByName(name string) (*Peer, error) {
 
 // DeletePeer deletes given peer from the store
 func DeletePeer(id string) error {
-	_, e := store.Store.Delete(context.TODO(), peerPrefix+id)
+	_, e := store.Store.Delete(context.TODO(), StorePrefix+id)
 	return e
 }
 
 // Exists checks if given peer is present in the store
 func Exists(id string) bool {
-	resp, e := store.Store.Get(context.TODO(), peerPrefix+id)
+	resp, e := store.Store.Get(context.TODO(), StorePrefix+id)
 	if e != nil {
 		return false
 	}
@@ -7,6 +7,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	config "github.com/spf13/viper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Server is the gRPC server
@@ -17,8 +18,20 @@ type Server struct {
 
 // New returns a new peerrpc.Server with registered gRPC services
 func New() *Server {
+	var opts []grpc.ServerOption
+
+	tlsConf, err := tlsConfig()
+	if err != nil {
+		// TODO: Don't use Fatal(), bubble up error till main()
+		// NOTE: Methods of suture.Service interface do not return error
+		log.WithError(err).Fatal("Failed to create peer RPC TLS configuration")
+	}
+	if tlsConf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
 	s := &Server{
-		grpc.NewServer(),
+		grpc.NewServer(opts...),
 	}
 	registerServices(s.server)
 
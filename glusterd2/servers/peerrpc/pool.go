@@ -0,0 +1,211 @@
+package peerrpc
+
+// This file implements a pool of gRPC client connections to peers, shared by
+// all the users of the peer RPC services. Connections are kept open and
+// reused, and are health checked in the background. A connection found to be
+// unhealthy is evicted from the pool, and the next request for it connects
+// again, trying each of the addresses of the peer in turn. As connections are
+// shared, an evicted connection is only closed once all its users have
+// released it.
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gluster/glusterd2/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+const healthCheckInterval = 10 * time.Second
+
+// dialTimeout is the time allowed to connect to an address of a peer
+var dialTimeout = 5 * time.Second
+
+type poolConn struct {
+	conn *grpc.ClientConn
+	// address is the address of the peer the connection is made to
+	address string
+	// users is the number of GetConn() callers which haven't released the
+	// connection yet
+	users int
+	// evicted is set once the connection is removed from the pool
+	evicted bool
+}
+
+var (
+	pool = struct {
+		sync.Mutex
+		conns map[string]*poolConn
+	}{conns: make(map[string]*poolConn)}
+
+	healthCheckOnce sync.Once
+)
+
+// GetConn returns a connection to the peer with the given id from the pool.
+// If there is no connection to the peer, one is made to the first of the
+// given addresses of the peer which can be connected to. The id can be any
+// string identifying the peer, like its address if its ID isn't known yet.
+// The returned connection must not be closed by the caller, who must instead
+// call the returned release function once done with it.
+func GetConn(id string, addresses []string) (*grpc.ClientConn, func(), error) {
+	healthCheckOnce.Do(func() {
+		go healthCheck()
+	})
+
+	pool.Lock()
+	pc, ok := pool.conns[id]
+	if ok {
+		pc.users++
+	}
+	pool.Unlock()
+	if ok {
+		return pc.conn, pc.release, nil
+	}
+
+	pc, err := dial(addresses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pool.Lock()
+	defer pool.Unlock()
+	if existing, ok := pool.conns[id]; ok {
+		// Another request connected to the peer in the meantime
+		pc.conn.Close()
+		existing.users++
+		return existing.conn, existing.release, nil
+	}
+	pc.users = 1
+	pool.conns[id] = pc
+
+	return pc.conn, pc.release, nil
+}
+
+// release releases the connection for one of its users, closing it if it
+// was evicted and has no users left
+func (pc *poolConn) release() {
+	pool.Lock()
+	defer pool.Unlock()
+
+	pc.users--
+	if pc.evicted && pc.users == 0 {
+		pc.conn.Close()
+	}
+}
+
+// evict removes the given connection to the peer with the given id from the
+// pool, closing it if it has no users. The pool must be locked.
+func evict(id string, pc *poolConn) {
+	if pool.conns[id] == pc {
+		delete(pool.conns, id)
+	}
+	if pc.evicted {
+		return
+	}
+	pc.evicted = true
+	if pc.users == 0 {
+		pc.conn.Close()
+	}
+}
+
+// ReportError evicts the given connection to the peer with the given id from
+// the pool if err, returned by a RPC call made with it, shows that the peer is
+// unreachable. The next GetConn() for the peer connects again, possibly to
+// another address.
+func ReportError(id string, conn *grpc.ClientConn, err error) {
+	if grpc.Code(err) != codes.Unavailable {
+		return
+	}
+	log.WithError(err).WithField("peer", id).Debug("peer unreachable, evicting connection")
+
+	pool.Lock()
+	defer pool.Unlock()
+	if pc, ok := pool.conns[id]; ok && pc.conn == conn {
+		evict(id, pc)
+	}
+}
+
+// EvictConn evicts the connection to the peer with the given id, if any, from
+// the pool. It is closed once it has been released by all its users.
+func EvictConn(id string) {
+	pool.Lock()
+	defer pool.Unlock()
+	if pc, ok := pool.conns[id]; ok {
+		evict(id, pc)
+	}
+}
+
+// dial connects to the first of the given peer addresses which can be
+// connected to
+func dial(addresses []string) (*poolConn, error) {
+	tlsConf, err := tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.New("no peer address to connect to")
+	for _, address := range addresses {
+		remote, e := utils.FormRemotePeerAddress(address)
+		if e != nil {
+			err = e
+			continue
+		}
+
+		opts := []grpc.DialOption{grpc.WithBlock(), grpc.WithTimeout(dialTimeout)}
+		if tlsConf != nil {
+			conf := tlsConf.Clone()
+			conf.ServerName, _, _ = net.SplitHostPort(remote)
+			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(conf)))
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+
+		conn, e := grpc.Dial(remote, opts...)
+		if e != nil {
+			log.WithError(e).WithField("remote", remote).Debug("failed to connect to peer address")
+			err = e
+			continue
+		}
+
+		log.WithField("remote", remote).Debug("connected to remote")
+		return &poolConn{conn, remote}, nil
+	}
+
+	return nil, err
+}
+
+// healthCheck periodically checks that the addresses of the pooled
+// connections can be reached, and evicts the connections which can't
+func healthCheck() {
+	for range time.Tick(healthCheckInterval) {
+		pool.Lock()
+		conns := make(map[string]*poolConn, len(pool.conns))
+		for id, pc := range pool.conns {
+			conns[id] = pc
+		}
+		pool.Unlock()
+
+		for id, pc := range conns {
+			c, err := net.DialTimeout("tcp", pc.address, dialTimeout)
+			if err == nil {
+				c.Close()
+				continue
+			}
+
+			log.WithError(err).WithFields(log.Fields{
+				"peer":   id,
+				"remote": pc.address,
+			}).Debug("health check failed, evicting connection")
+
+			pool.Lock()
+			evict(id, pc)
+			pool.Unlock()
+		}
+	}
+}
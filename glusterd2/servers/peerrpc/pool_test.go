@@ -0,0 +1,263 @@
+package peerrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	config "github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// empty is a message without fields, used to call methods which no server
+// implements. Such calls fail with codes.Unimplemented if the connection to
+// the server works.
+type empty struct{}
+
+func (*empty) Reset()         {}
+func (*empty) String() string { return "" }
+func (*empty) ProtoMessage()  {}
+
+// ping calls a method which isn't implemented by the server over the given
+// connection
+func ping(conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return grpc.Invoke(ctx, "/peerrpc.Test/Ping", &empty{}, &empty{}, conn)
+}
+
+// TestGetConn validates pooling and failover to the other peer addresses
+func TestGetConn(t *testing.T) {
+	dialTimeout = 500 * time.Millisecond
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	go s.Serve(l)
+	defer s.Stop()
+
+	// Nothing listens on the first address
+	unused, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	unused.Close()
+
+	conn, release, err := GetConn("test-peer", []string{unused.Addr().String(), l.Addr().String()})
+	assert.Nil(t, err)
+	assert.Equal(t, l.Addr().String(), pool.conns["test-peer"].address)
+
+	same, releaseSame, err := GetConn("test-peer", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, conn, same)
+
+	// Errors other than unreachable peers keep the connection
+	ReportError("test-peer", conn, grpc.Errorf(codes.Internal, "internal error"))
+	assert.Contains(t, pool.conns, "test-peer")
+	ReportError("test-peer", conn, grpc.Errorf(codes.Unavailable, "unreachable"))
+	assert.NotContains(t, pool.conns, "test-peer")
+
+	// The evicted connection is only closed once released by all its
+	// users
+	assert.Equal(t, codes.Unimplemented, grpc.Code(ping(conn)))
+	release()
+	assert.Equal(t, codes.Unimplemented, grpc.Code(ping(conn)))
+	releaseSame()
+	assert.Equal(t, grpc.ErrClientConnClosing, ping(conn))
+
+	_, _, err = GetConn("test-peer", []string{unused.Addr().String()})
+	assert.NotNil(t, err)
+}
+
+// TestEvictConn validates that evicting a connection doesn't close it for its
+// users, and that errors reported for an evicted connection don't evict the
+// new connection to the same peer
+func TestEvictConn(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	go s.Serve(l)
+	defer s.Stop()
+
+	addresses := []string{l.Addr().String()}
+	old, releaseOld, err := GetConn("test-evict", addresses)
+	assert.Nil(t, err)
+
+	EvictConn("test-evict")
+	assert.NotContains(t, pool.conns, "test-evict")
+	assert.Equal(t, codes.Unimplemented, grpc.Code(ping(old)))
+
+	conn, release, err := GetConn("test-evict", addresses)
+	assert.Nil(t, err)
+	defer release()
+	assert.NotEqual(t, old, conn)
+
+	ReportError("test-evict", old, grpc.Errorf(codes.Unavailable, "unreachable"))
+	assert.Equal(t, conn, pool.conns["test-evict"].conn)
+
+	releaseOld()
+	assert.Equal(t, grpc.ErrClientConnClosing, ping(old))
+	assert.Equal(t, codes.Unimplemented, grpc.Code(ping(conn)))
+	EvictConn("test-evict")
+}
+
+// TestTLSConfig validates that all the TLS options are required
+func TestTLSConfig(t *testing.T) {
+	conf, err := tlsConfig()
+	assert.Nil(t, err)
+	assert.Nil(t, conf)
+
+	config.Set("peer-cert-file", "cert.pem")
+	defer config.Set("peer-cert-file", "")
+	_, err = tlsConfig()
+	assert.NotNil(t, err)
+}
+
+// testCA is a CA generated for the tests, which signs certificates for
+// 127.0.0.1
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T, dir, name string) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &testCA{cert, key, path.Join(dir, name)}
+	if err := os.Mkdir(ca.dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	ca.writePEM(t, "ca.pem", "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) writePEM(t *testing.T, name, typ string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := ioutil.WriteFile(path.Join(ca.dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// issue writes a certificate and key signed by the CA, usable by both the
+// servers and the clients, to the directory of the CA
+func (ca *testCA) issue(t *testing.T, serial int64) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.writePEM(t, "cert.pem", "CERTIFICATE", der)
+	ca.writePEM(t, "key.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+// use sets the peer TLS options to the certificate and CA in the directory of
+// the CA
+func (ca *testCA) use() {
+	config.Set("peer-cert-file", path.Join(ca.dir, "cert.pem"))
+	config.Set("peer-key-file", path.Join(ca.dir, "key.pem"))
+	config.Set("peer-ca-file", path.Join(ca.dir, "ca.pem"))
+}
+
+// TestMutualTLS validates that peers with certificates signed by the same CA
+// can connect to each other, and that the server rejects clients with
+// certificates signed by another CA
+func TestMutualTLS(t *testing.T) {
+	dialTimeout = 2 * time.Second
+
+	dir, err := ioutil.TempDir("", "peerrpc-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() {
+		for _, key := range []string{"peer-cert-file", "peer-key-file", "peer-ca-file"} {
+			config.Set(key, "")
+		}
+	}()
+
+	trusted := newTestCA(t, dir, "trusted")
+	trusted.issue(t, 2)
+	untrusted := newTestCA(t, dir, "untrusted")
+	untrusted.issue(t, 3)
+
+	// The server is created with the TLS options of the trusted peers
+	trusted.use()
+	s := New()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go s.server.Serve(l)
+	defer s.server.Stop()
+
+	addresses := []string{l.Addr().String()}
+	conn, release, err := GetConn("test-tls", addresses)
+	if assert.Nil(t, err) {
+		assert.Equal(t, codes.Unimplemented, grpc.Code(ping(conn)))
+		release()
+		EvictConn("test-tls")
+	}
+
+	// The server and the untrusted client reject each other's certificates.
+	// Depending on the TLS version, the client may only see the rejection
+	// when making the first call.
+	untrusted.use()
+	conn, release, err = GetConn("test-tls-untrusted", addresses)
+	if err == nil {
+		assert.NotEqual(t, codes.Unimplemented, grpc.Code(ping(conn)))
+		release()
+		EvictConn("test-tls-untrusted")
+	}
+
+	// Clients without TLS can't connect either
+	for _, key := range []string{"peer-cert-file", "peer-key-file", "peer-ca-file"} {
+		config.Set(key, "")
+	}
+	conn, release, err = GetConn("test-tls-insecure", addresses)
+	if err == nil {
+		assert.NotEqual(t, codes.Unimplemented, grpc.Code(ping(conn)))
+		release()
+		EvictConn("test-tls-insecure")
+	}
+}
@@ -0,0 +1,51 @@
+package peerrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+
+	config "github.com/spf13/viper"
+)
+
+// tlsConfig returns the TLS configuration used for the peer RPC server and
+// clients, or nil if TLS is not enabled. TLS is enabled by setting the
+// peer-cert-file, peer-key-file and peer-ca-file options. Peers then
+// authenticate each other with certificates signed by the given CA.
+func tlsConfig() (*tls.Config, error) {
+	certfile := config.GetString("peer-cert-file")
+	keyfile := config.GetString("peer-key-file")
+	cafile := config.GetString("peer-ca-file")
+
+	if certfile == "" && keyfile == "" && cafile == "" {
+		return nil, nil
+	}
+	if certfile == "" || keyfile == "" || cafile == "" {
+		return nil, errors.New("peer-cert-file, peer-key-file and peer-ca-file are all required for peer RPC TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certfile, keyfile)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := ioutil.ReadFile(cafile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates found in peer-ca-file")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// Used by the server to verify clients
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		// Used by clients to verify the server
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/transaction"
 	"github.com/gluster/glusterd2/pkg/api"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +19,11 @@ import (
 type APIError struct {
 	Code  api.ErrorCode `json:"error_code"`
 	Error string        `json:"error"`
+	// Nodes contains the per-node results of a failed transaction step
+	Nodes []transaction.NodeResult `json:"nodes,omitempty"`
+	// UndoFailures contains the results of the rollback steps that failed
+	// after a transaction step failed
+	UndoFailures []*transaction.StepResult `json:"undo-failures,omitempty"`
 }
 
 // UnmarshalRequest unmarshals JSON in `r` into `v`
@@ -53,6 +61,97 @@ func SendHTTPError(ctx context.Context, w http.ResponseWriter, statusCode int, e
 	}
 }
 
+// SendHTTPTxnError sends the error returned by a failed transaction to the
+// client. If a transaction step failed, the results of the step on each node
+// are included in the response. Lock timeouts are reported as HTTP 409, and
+// locks interrupted by the loss of the store session as HTTP 503.
+func SendHTTPTxnError(ctx context.Context, w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	switch transaction.Cause(err) {
+	case transaction.ErrLockTimeout:
+		statusCode = http.StatusConflict
+	case store.ErrSessionLost:
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	resp := APIError{Code: api.ErrCodeDefault, Error: err.Error()}
+	if serr, ok := err.(*transaction.StepError); ok {
+		resp.Nodes = serr.Nodes
+		resp.UndoFailures = serr.UndoFailures
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger := GetReqLogger(ctx)
+		logger.WithError(err).Error("Failed to send the response -", resp)
+	}
+}
+
+// IsAsyncRequest returns true if the client requested the operation to be run
+// asynchronously, using the `async` query parameter.
+func IsAsyncRequest(r *http.Request) bool {
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	return async
+}
+
+// SendHTTPJobResponse sends the response for an operation being run
+// asynchronously. The response contains the job tracking the operation.
+func SendHTTPJobResponse(ctx context.Context, w http.ResponseWriter, job *transaction.Job) {
+	w.Header().Set("Location", "/v1/jobs/"+job.ID.String())
+	SendHTTPResponse(ctx, w, http.StatusAccepted, job)
+}
+
+// DryRunResult is the response to an operation requested as a dry run
+type DryRunResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Steps contains the per-node results of the validation steps that were run
+	Steps []*transaction.StepResult `json:"steps"`
+}
+
+// IsDryRunRequest returns true if the client requested the operation to only
+// be validated, using the `dry-run` query parameter.
+func IsDryRunRequest(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry-run"))
+	return dryRun
+}
+
+// SendHTTPDryRunResponse sends the verdict of a transaction run as a dry run,
+// with err being the error returned by Txn.Do(). Failed validation steps are
+// part of the verdict, other errors are sent as with SendHTTPTxnError.
+func SendHTTPDryRunResponse(ctx context.Context, w http.ResponseWriter, txn *transaction.Txn, err error) {
+	if err != nil {
+		serr, ok := err.(*transaction.StepError)
+		if !ok || transaction.Cause(err) == transaction.ErrLockTimeout || !isValidationStep(txn, serr.StepFunc) {
+			SendHTTPTxnError(ctx, w, err)
+			return
+		}
+	}
+
+	resp := DryRunResult{Valid: err == nil, Steps: txn.ValidationResults()}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	SendHTTPResponse(ctx, w, http.StatusOK, resp)
+}
+
+// SendHTTPDryRunValid sends the verdict of an operation which isn't run as a
+// transaction, requested as a dry run, once all its checks have passed. Failed
+// checks are sent as errors, like for requests which aren't dry runs.
+func SendHTTPDryRunValid(ctx context.Context, w http.ResponseWriter) {
+	SendHTTPResponse(ctx, w, http.StatusOK, DryRunResult{Valid: true, Steps: []*transaction.StepResult{}})
+}
+
+func isValidationStep(txn *transaction.Txn, stepFunc string) bool {
+	for _, s := range txn.Steps {
+		if s.Validate && s.DoFunc == stepFunc {
+			return true
+		}
+	}
+	return false
+}
+
 // GetReqLogger returns a request-scoped logger with request ID as a logging field.
 func GetReqLogger(ctx context.Context) *log.Entry {
 	return ctx.Value(gdctx.ReqLoggerKey).(*log.Entry)
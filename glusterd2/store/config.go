@@ -20,6 +20,20 @@ const (
 	etcdCURLsOpt     = "etcdcurls"
 	etcdPURLsOpt     = "etcdpurls"
 	etcdLogFileOpt   = "etcdlogfile"
+	etcdZoneOpt      = "etcdzone"
+	etcdRackOpt      = "etcdrack"
+
+	etcdCompactionIntervalOpt = "etcdcompaction-interval"
+	etcdDefragIntervalOpt     = "etcddefrag-interval"
+
+	etcdCertFileOpt     = "etcdcertfile"
+	etcdKeyFileOpt      = "etcdkeyfile"
+	etcdCAFileOpt       = "etcdcafile"
+	etcdAutoTLSOpt      = "etcdautotls"
+	etcdPeerCertFileOpt = "etcdpeercertfile"
+	etcdPeerKeyFileOpt  = "etcdpeerkeyfile"
+	etcdPeerCAFileOpt   = "etcdpeercafile"
+	etcdPeerAutoTLSOpt  = "etcdpeerautotls"
 
 	defaultEtcdLogFile = "etcd.log"
 
@@ -34,6 +48,18 @@ func InitFlags() {
 	flag.StringSlice(etcdEndpointsOpt, nil, fmt.Sprintf("ETCD endpoints of a remote etcd cluster for the store to connect to. (Defaults to: %s)", elasticetcd.DefaultEndpoint))
 	flag.StringSlice(etcdCURLsOpt, nil, fmt.Sprintf("URLs which etcd server will use for peer to peer communication. (Defaults to: %s)", elasticetcd.DefaultCURL))
 	flag.StringSlice(etcdPURLsOpt, nil, fmt.Sprintf("URLs which etcd server will use to receive etcd client requests. (Defaults to: %s)", elasticetcd.DefaultPURL))
+	flag.String(etcdZoneOpt, "", "Zone of this node. The embedded etcd servers are spread across zones.")
+	flag.String(etcdRackOpt, "", "Rack of this node in its zone. The embedded etcd servers are spread across racks.")
+	flag.String(etcdCompactionIntervalOpt, "", fmt.Sprintf("Interval at which the embedded etcd history is compacted, 0 to disable. (Defaults to: %s)", elasticetcd.DefaultCompactionInterval))
+	flag.String(etcdDefragIntervalOpt, "", fmt.Sprintf("Interval at which the embedded etcd servers are defragmented, 0 to disable. (Defaults to: %s)", elasticetcd.DefaultDefragInterval))
+	flag.String(etcdCertFileOpt, "", "Certificate for etcd client connections. Presented by the embedded etcd servers, and by the store client if --etcdcafile is given.")
+	flag.String(etcdKeyFileOpt, "", "Key of the certificate for etcd client connections.")
+	flag.String(etcdCAFileOpt, "", "CA to verify etcd client connections. If given, the embedded etcd servers require client certificates signed by it.")
+	flag.Bool(etcdAutoTLSOpt, false, "Use generated self-signed certificates for etcd client connections, if no certificate is given.")
+	flag.String(etcdPeerCertFileOpt, "", "Certificate for the peer connections of the embedded etcd servers.")
+	flag.String(etcdPeerKeyFileOpt, "", "Key of the certificate for the peer connections of the embedded etcd servers.")
+	flag.String(etcdPeerCAFileOpt, "", "CA to verify the peer connections of the embedded etcd servers.")
+	flag.Bool(etcdPeerAutoTLSOpt, false, "Use generated self-signed certificates for the peer connections of the embedded etcd servers, if no certificate is given.")
 }
 
 // Config is the GD2 store configuration
@@ -43,6 +69,30 @@ type Config struct {
 	PURLs     []string
 	NoEmbed   bool
 
+	// Zone and Rack are the failure domain of this node, used to spread
+	// the embedded etcd servers
+	Zone string
+	Rack string
+
+	// CompactionInterval and DefragInterval are the intervals, as
+	// durations like "1h", at which the embedded etcd is compacted and
+	// defragmented
+	CompactionInterval string
+	DefragInterval     string
+
+	// TLS options of the etcd client connections, used by the store client
+	// and by the embedded etcd servers. When TLS is used, the http URLs are
+	// used with https instead.
+	ClientCertFile string
+	ClientKeyFile  string
+	ClientCAFile   string
+	ClientAutoTLS  bool
+	// TLS options of the peer connections of the embedded etcd servers
+	PeerCertFile string
+	PeerKeyFile  string
+	PeerCAFile   string
+	PeerAutoTLS  bool
+
 	Dir      string
 	ConfFile string
 }
@@ -50,15 +100,64 @@ type Config struct {
 // NewConfig returns a new store Config with defaults
 func NewConfig() *Config {
 	return &Config{
-		[]string{elasticetcd.DefaultEndpoint},
-		[]string{elasticetcd.DefaultCURL},
-		[]string{elasticetcd.DefaultPURL},
-		false,
-		path.Join(config.GetString("localstatedir"), "store"),
-		path.Join(config.GetString("localstatedir"), storeConfFile),
+		Endpoints: []string{elasticetcd.DefaultEndpoint},
+		CURLs:     []string{elasticetcd.DefaultCURL},
+		PURLs:     []string{elasticetcd.DefaultPURL},
+		NoEmbed:   false,
+
+		CompactionInterval: elasticetcd.DefaultCompactionInterval.String(),
+		DefragInterval:     elasticetcd.DefaultDefragInterval.String(),
+
+		Dir:      path.Join(config.GetString("localstatedir"), "store"),
+		ConfFile: path.Join(config.GetString("localstatedir"), storeConfFile),
 	}
 }
 
+// ClientTLS returns the TLS options of the etcd client connections
+func (c *Config) ClientTLS() elasticetcd.TLSInfo {
+	return elasticetcd.TLSInfo{
+		CertFile: c.ClientCertFile,
+		KeyFile:  c.ClientKeyFile,
+		CAFile:   c.ClientCAFile,
+		AutoTLS:  c.ClientAutoTLS,
+	}
+}
+
+// PeerTLS returns the TLS options of the embedded etcd peer connections
+func (c *Config) PeerTLS() elasticetcd.TLSInfo {
+	return elasticetcd.TLSInfo{
+		CertFile: c.PeerCertFile,
+		KeyFile:  c.PeerKeyFile,
+		CAFile:   c.PeerCAFile,
+		AutoTLS:  c.PeerAutoTLS,
+	}
+}
+
+// ValidateTLS returns an error if the TLS certificates, keys or CAs of the
+// config can't be loaded
+func (c *Config) ValidateTLS() error {
+	if err := c.ClientTLS().Validate(); err != nil {
+		return fmt.Errorf("invalid etcd client TLS options: %s", err)
+	}
+	if err := c.PeerTLS().Validate(); err != nil {
+		return fmt.Errorf("invalid etcd peer TLS options: %s", err)
+	}
+	return nil
+}
+
+// ResetTLS resets the TLS options to the ones given in the GD2 config, dropping
+// the options received from the cluster this node was part of
+func (c *Config) ResetTLS() {
+	c.ClientCertFile = config.GetString(etcdCertFileOpt)
+	c.ClientKeyFile = config.GetString(etcdKeyFileOpt)
+	c.ClientCAFile = config.GetString(etcdCAFileOpt)
+	c.ClientAutoTLS = config.GetBool(etcdAutoTLSOpt)
+	c.PeerCertFile = config.GetString(etcdPeerCertFileOpt)
+	c.PeerKeyFile = config.GetString(etcdPeerKeyFileOpt)
+	c.PeerCAFile = config.GetString(etcdPeerCAFileOpt)
+	c.PeerAutoTLS = config.GetBool(etcdPeerAutoTLSOpt)
+}
+
 // Save saves the store config to a file in the localstatedir
 func (c *Config) Save() error {
 	b, err := toml.Marshal(*c)
@@ -109,6 +208,69 @@ func GetConfig() *Config {
 		saveconf = true
 		conf.PURLs = purls
 	}
+
+	if zone := config.GetString(etcdZoneOpt); zone != "" {
+		saveconf = true
+		conf.Zone = zone
+	}
+
+	if rack := config.GetString(etcdRackOpt); rack != "" {
+		saveconf = true
+		conf.Rack = rack
+	}
+
+	if interval := config.GetString(etcdCompactionIntervalOpt); interval != "" {
+		saveconf = true
+		conf.CompactionInterval = interval
+	}
+
+	if interval := config.GetString(etcdDefragIntervalOpt); interval != "" {
+		saveconf = true
+		conf.DefragInterval = interval
+	}
+
+	if certfile := config.GetString(etcdCertFileOpt); certfile != "" {
+		saveconf = true
+		conf.ClientCertFile = certfile
+	}
+
+	if keyfile := config.GetString(etcdKeyFileOpt); keyfile != "" {
+		saveconf = true
+		conf.ClientKeyFile = keyfile
+	}
+
+	if cafile := config.GetString(etcdCAFileOpt); cafile != "" {
+		saveconf = true
+		conf.ClientCAFile = cafile
+	}
+
+	if certfile := config.GetString(etcdPeerCertFileOpt); certfile != "" {
+		saveconf = true
+		conf.PeerCertFile = certfile
+	}
+
+	if keyfile := config.GetString(etcdPeerKeyFileOpt); keyfile != "" {
+		saveconf = true
+		conf.PeerKeyFile = keyfile
+	}
+
+	if cafile := config.GetString(etcdPeerCAFileOpt); cafile != "" {
+		saveconf = true
+		conf.PeerCAFile = cafile
+	}
+
+	// Auto TLS can only be enabled from the GD2 config, it is disabled by
+	// editing the store config file
+	if config.GetBool(etcdAutoTLSOpt) {
+		saveconf = true
+		conf.ClientAutoTLS = true
+	}
+
+	if config.GetBool(etcdPeerAutoTLSOpt) {
+		saveconf = true
+		conf.PeerAutoTLS = true
+	}
+
 	if config.IsSet(noEmbedOpt) {
 		saveconf = true
 		conf.NoEmbed = config.GetBool(noEmbedOpt)
@@ -8,4 +8,18 @@
 // github.com/gluster/glusterd2/pkg/elasticetcd package, which provides an
 // autoscaling etcd cluster, and allows GD2 to be used without much difficulties.
 // More details on how elasticetcd works can be found in its package documentation.
+//
+// The store keeps an etcd session, whose lease holds the liveness key of GD2
+// and its locks. If the lease expires, for example after a network partition,
+// a new session is created and the liveness key is published again.
+//
+// The embedded etcd history is compacted, and the etcd servers are
+// defragmented, periodically at the configured intervals, so that the etcd
+// backends stay within their quota. Snapshots of the etcd backend can be saved
+// under the localstatedir with SaveSnapshot.
+//
+// The etcd client connections, and the peer connections of the embedded etcd
+// servers, can use TLS. The TLS options are saved in the store config file,
+// and are sent to peers joining the cluster, which use them where they have
+// none configured.
 package store
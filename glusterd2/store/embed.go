@@ -2,6 +2,7 @@ package store
 
 import (
 	"path"
+	"time"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	"github.com/gluster/glusterd2/pkg/elasticetcd"
@@ -19,12 +20,18 @@ func newEmbedStore(sconf *Config) (*GDStore, error) {
 	}
 
 	log.WithFields(log.Fields{
-		"name":      econf.Name,
-		"datadir":   econf.Dir,
-		"logdir":    econf.LogDir,
-		"endpoints": econf.Endpoints.String(),
-		"curls":     econf.CURLs.String(),
-		"purls":     econf.PURLs.String(),
+		"name":       econf.Name,
+		"datadir":    econf.Dir,
+		"logdir":     econf.LogDir,
+		"endpoints":  econf.Endpoints.String(),
+		"curls":      econf.CURLs.String(),
+		"purls":      econf.PURLs.String(),
+		"zone":       econf.Domain.Zone,
+		"rack":       econf.Domain.Rack,
+		"compaction": econf.CompactionInterval,
+		"defrag":     econf.DefragInterval,
+		"clienttls":  !econf.ClientTLS.Empty(),
+		"peertls":    !econf.PeerTLS.Empty(),
 	}).Debug("starting embedded store")
 
 	ee, err := elasticetcd.New(econf)
@@ -33,7 +40,23 @@ func newEmbedStore(sconf *Config) (*GDStore, error) {
 		return nil, err
 	}
 
-	return &GDStore{*sconf, ee.Client(), ee.Session(), ee}, nil
+	return &GDStore{
+		conf:    *sconf,
+		Client:  ee.Client(),
+		ee:      ee,
+		session: ee.Session(),
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// Elastic returns the elastic etcd of the embedded store, which manages the
+// etcd servers run by the peers. ErrNotEmbedded is returned if the store is
+// not embedded.
+func (s *GDStore) Elastic() (*elasticetcd.ElasticEtcd, error) {
+	if s.ee == nil {
+		return nil, ErrNotEmbedded
+	}
+	return s.ee, nil
 }
 
 func (s *GDStore) closeEmbedStore() {
@@ -64,6 +87,21 @@ func getElasticConfig(sconf *Config) (*elasticetcd.Config, error) {
 	econf.Endpoints = endpoints
 	econf.CURLs = curls
 	econf.PURLs = purls
+	econf.Domain = elasticetcd.FailureDomain{Zone: sconf.Zone, Rack: sconf.Rack}
+	econf.ClientTLS = sconf.ClientTLS()
+	econf.PeerTLS = sconf.PeerTLS()
+
+	// Configs saved before the intervals were added keep the defaults
+	if sconf.CompactionInterval != "" {
+		if econf.CompactionInterval, err = time.ParseDuration(sconf.CompactionInterval); err != nil {
+			return nil, err
+		}
+	}
+	if sconf.DefragInterval != "" {
+		if econf.DefragInterval, err = time.ParseDuration(sconf.DefragInterval); err != nil {
+			return nil, err
+		}
+	}
 
 	return econf, nil
 }
@@ -2,12 +2,14 @@ package store
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -44,9 +46,74 @@ func (s *GDStore) IsNodeAlive(nodeID interface{}) bool {
 }
 
 func (s *GDStore) publishLiveness() error {
-	// publish liveness of this instance into the store
+	s.sessionLock.RLock()
+	value := s.liveness
+	s.sessionLock.RUnlock()
+
+	return s.PublishLiveness(value)
+}
+
+// PublishLiveness publishes the liveness of this instance into the store,
+// with the given value, like a health record. The liveness key exists as long
+// as the store session of this instance is alive, and is published again
+// with the last value when the session is recreated.
+func (s *GDStore) PublishLiveness(value string) error {
+	s.sessionLock.Lock()
+	s.liveness = value
+	s.sessionLock.Unlock()
+
 	key := livenessKeyPrefix + gdctx.MyUUID.String()
-	_, err := s.Put(context.TODO(), key, "", clientv3.WithLease(s.Session.Lease()))
+	_, err := s.Put(context.TODO(), key, value, clientv3.WithLease(s.Session().Lease()))
 
 	return err
 }
+
+// GetLiveness returns the value published with the liveness key of the given
+// node and the revision of its last change, and whether the node is alive as
+// seen by the store
+func (s *GDStore) GetLiveness(nodeID string) ([]byte, int64, bool, error) {
+	resp, err := s.Client.Get(context.TODO(), livenessKeyPrefix+nodeID)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if resp.Count == 0 {
+		return nil, 0, false, nil
+	}
+	return resp.Kvs[0].Value, resp.Kvs[0].ModRevision, true, nil
+}
+
+// AliveNodes returns the IDs of the nodes which are alive as seen by the store
+func (s *GDStore) AliveNodes() ([]string, error) {
+	resp, err := s.Client.Get(context.TODO(), livenessKeyPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		nodes = append(nodes, strings.TrimPrefix(string(kv.Key), livenessKeyPrefix))
+	}
+	return nodes, nil
+}
+
+// WatchLiveness calls fn with the ID of every node which becomes alive or
+// goes down, as seen by the store, until ctx is cancelled
+func (s *GDStore) WatchLiveness(ctx context.Context, fn func(nodeID string, alive bool)) {
+	wch := s.Client.Watch(ctx, livenessKeyPrefix, clientv3.WithPrefix())
+	for wresp := range wch {
+		if err := wresp.Err(); err != nil {
+			log.WithError(err).Error("liveness watch failed")
+			return
+		}
+
+		for _, ev := range wresp.Events {
+			nodeID := strings.TrimPrefix(string(ev.Kv.Key), livenessKeyPrefix)
+			switch {
+			case ev.Type == clientv3.EventTypeDelete:
+				fn(nodeID, false)
+			case ev.IsCreate():
+				fn(nodeID, true)
+			}
+		}
+	}
+}
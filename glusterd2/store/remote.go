@@ -5,16 +5,34 @@ import (
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/coreos/etcd/pkg/types"
 	log "github.com/sirupsen/logrus"
 )
 
 func newRemoteStore(conf *Config) (*GDStore, error) {
 
+	tlsinfo := conf.ClientTLS()
+	tlsconf, e := tlsinfo.ClientConfig()
+	if e != nil {
+		log.WithError(e).Error("failed to create etcd client TLS config")
+		return nil, e
+	}
+	endpoints := conf.Endpoints
+	if !tlsinfo.Empty() {
+		urls, e := types.NewURLs(endpoints)
+		if e != nil {
+			log.WithError(e).Error("failed to parse etcd endpoints")
+			return nil, e
+		}
+		endpoints = tlsinfo.SecureURLs(urls).StringSlice()
+	}
+
 	c, e := clientv3.New(clientv3.Config{
-		Endpoints:        conf.Endpoints,
+		Endpoints:        endpoints,
 		AutoSyncInterval: 30 * time.Second,
 		DialTimeout:      5 * time.Second,
 		RejectOldCluster: true,
+		TLS:              tlsconf,
 	})
 	if e != nil {
 		log.WithError(e).Error("failed to create etcd client")
@@ -32,18 +50,24 @@ func newRemoteStore(conf *Config) (*GDStore, error) {
 		return nil, e
 	}
 
-	return &GDStore{*conf, c, s, nil}, nil
+	return &GDStore{
+		conf:    *conf,
+		Client:  c,
+		session: s,
+		stop:    make(chan struct{}),
+	}, nil
 }
 
 func (s *GDStore) closeRemoteStore() {
-	s.Session.Orphan()
+	session := s.Session()
+	session.Orphan()
 	if e := s.Client.Close(); e != nil {
 		log.WithError(e).Warn("failed to close etcd client connection")
 	}
 	// FIXME: We should close the session first and then the client but it
 	// doesn't work because restart of embedded etcd server when using v3
 	// has issues.
-	if e := s.Session.Close(); e != nil {
+	if e := session.Close(); e != nil {
 		log.WithError(e).Warn("failed to close etcd session")
 	}
 }
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/coreos/etcd/clientv3/concurrency"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSessionLost is returned when an operation depending on the store
+// session, like waiting for a lock, is interrupted because the session lease
+// expired
+var ErrSessionLost = errors.New("store session lost")
+
+var (
+	// renewInterval is the interval between attempts to create a new session
+	renewInterval = 1 * time.Second
+	// maxRenewInterval is the limit for the interval between attempts to
+	// create a new session
+	maxRenewInterval = 30 * time.Second
+)
+
+// Session returns the current store session. The session is replaced by a
+// new one when its lease expires, so the session must not be kept by the
+// caller beyond the operation it is used for. Keys attached to the lease of
+// the session, or locks obtained with it, are lost when its Done() channel is
+// closed.
+func (s *GDStore) Session() *concurrency.Session {
+	s.sessionLock.RLock()
+	defer s.sessionLock.RUnlock()
+	return s.session
+}
+
+// keepSession waits for the store session to end, which happens when its
+// lease can't be kept alive, for example during a network partition or a long
+// pause of the process, and replaces it with a new session, till the store is
+// closed. The liveness of this instance, which was lost with the old lease, is
+// published again.
+func (s *GDStore) keepSession() {
+	for {
+		select {
+		case <-s.Session().Done():
+		case <-s.stop:
+			return
+		}
+		// Done() is also closed when the session is closed with the store
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		log.Warn("store session lost, creating a new session")
+		session, err := s.renewSession()
+		if err != nil {
+			// The store is closed
+			return
+		}
+
+		s.sessionLock.Lock()
+		s.session = session
+		s.sessionLock.Unlock()
+
+		if err := s.publishLiveness(); err != nil {
+			log.WithError(err).Error("failed to publish liveness with new store session")
+		}
+		log.WithField("lease", session.Lease()).Info("store session recreated")
+	}
+}
+
+// renewSession creates a new store session, retrying with an exponential
+// backoff till it succeeds or the store is closed
+func (s *GDStore) renewSession() (*concurrency.Session, error) {
+	interval := renewInterval
+	for {
+		session, err := s.newSession()
+		if err == nil {
+			return session, nil
+		}
+		log.WithError(err).WithField("retry-in", interval).Warn("failed to create a new store session")
+
+		select {
+		case <-time.After(interval):
+		case <-s.stop:
+			return nil, errors.New("store closed")
+		}
+		if interval *= 2; interval > maxRenewInterval {
+			interval = maxRenewInterval
+		}
+	}
+}
+
+// newSession creates a new session for the store. The elastic etcd of an
+// embedded store owns the session, and needs to rejoin the elastic cluster
+// with it.
+func (s *GDStore) newSession() (*concurrency.Session, error) {
+	if s.ee != nil {
+		return s.ee.RenewSession()
+	}
+	return concurrency.NewSession(s.Client, concurrency.WithTTL(sessionTTL))
+}
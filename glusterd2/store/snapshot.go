@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+// Snapshots are saved in this directory under the localstatedir, outside the
+// store data dir, so that they are kept when the store is destroyed
+const snapshotDir = "store-snapshots"
+
+// SaveSnapshot saves a snapshot of the etcd backend, taken from one of the
+// etcd servers the store is connected to, in the snapshot directory under the
+// localstatedir. The path of the saved snapshot is returned.
+func (s *GDStore) SaveSnapshot(ctx context.Context) (string, error) {
+	dir := path.Join(config.GetString("localstatedir"), snapshotDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	// The timestamp only has a resolution of a second, so snapshots saved
+	// concurrently are told apart by a random suffix
+	name := path.Join(dir, fmt.Sprintf("snapshot-%s-%s.db",
+		time.Now().UTC().Format("20060102T150405Z"), uuid.NewRandom().String()[:8]))
+	// The snapshot is written to a partial file first, so that an
+	// interrupted snapshot is never mistaken for a complete one
+	partName := name + ".part"
+
+	rc, err := s.Snapshot(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	// Never write over another snapshot
+	f, err := os.OpenFile(partName, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := io.Copy(f, rc)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(partName)
+		return "", err
+	}
+
+	if err := os.Rename(partName, name); err != nil {
+		os.Remove(partName)
+		return "", err
+	}
+
+	log.WithFields(log.Fields{
+		"path": name,
+		"size": n,
+	}).Info("saved store snapshot")
+
+	return name, nil
+}
@@ -25,6 +25,9 @@ var (
 
 	// ErrStoreInitedAlready is returned when the store is already intialized
 	ErrStoreInitedAlready = errors.New("store has been intialized already")
+	// ErrNotEmbedded is returned when trying to manage the embedded store
+	// while an external etcd cluster is used
+	ErrNotEmbedded = errors.New("store is not embedded, it is managed externally")
 )
 
 // GDStore is the GlusterD centralized store
@@ -32,9 +35,19 @@ type GDStore struct {
 	conf Config
 
 	*clientv3.Client
-	*concurrency.Session
 
 	ee *elasticetcd.ElasticEtcd
+
+	// session is replaced when its lease expires, so it must be accessed
+	// using Session()
+	session     *concurrency.Session
+	sessionLock sync.RWMutex
+	// liveness is the value last published with the liveness key, which is
+	// published again with a new session
+	liveness string
+	// stop is closed when the store is closed, to stop recreating the
+	// session
+	stop chan struct{}
 }
 
 // Init initializes the GD2 store
@@ -93,12 +106,14 @@ func New(conf *Config) (*GDStore, error) {
 	if err = store.publishLiveness(); err != nil {
 		return nil, err
 	}
+	go store.keepSession()
 
 	return store, nil
 }
 
 // Close closes the store connections
 func (s *GDStore) Close() {
+	close(s.stop)
 	if s.ee != nil {
 		s.closeEmbedStore()
 	} else {
@@ -112,6 +127,11 @@ func (s *GDStore) Destroy() {
 	os.RemoveAll(s.conf.Dir)
 }
 
+// Config returns the config the store was created with
+func (s *GDStore) Config() Config {
+	return s.conf
+}
+
 // UpdateEndpoints updates the configured endpoints and saves them
 func (s *GDStore) UpdateEndpoints() error {
 	if err := s.Sync(s.Ctx()); err != nil {
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"net"
+	"path"
+
+	config "github.com/spf13/viper"
+)
+
+// InitTestStore starts an embedded store, keeping its data and logs in the
+// given directory and with its etcd server listening on free local ports, and
+// makes it the default Store. It is meant for the tests of the packages using
+// the store. gdctx.MyUUID must be set before. The returned function destroys
+// the store and restores the previous default Store.
+func InitTestStore(dir string) (func(), error) {
+	var urls []string
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, "http://"+l.Addr().String())
+		l.Close()
+	}
+
+	config.Set("logdir", path.Join(dir, "log"))
+
+	conf := NewConfig()
+	conf.CURLs = urls[:1]
+	conf.PURLs = urls[1:]
+	conf.Dir = path.Join(dir, "store")
+	conf.ConfFile = path.Join(dir, storeConfFile)
+
+	s, err := New(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+	old := Store
+	Store = s
+	lock.Unlock()
+
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		s.Destroy()
+		Store = old
+	}, nil
+}
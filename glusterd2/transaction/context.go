@@ -26,6 +26,10 @@ type TxnCtx interface {
 	Logger() log.FieldLogger
 	// Prefix returns the prefix to be used for storing values
 	Prefix() string
+	// Context returns the context.Context of the transaction, which is
+	// cancelled when the transaction or the step being run is aborted.
+	// StepFuncs doing long running or blocking operations should use it.
+	Context() context.Context
 }
 
 // Tctx represents structure for transaction context
@@ -35,6 +39,8 @@ type Tctx struct {
 	logFields log.Fields
 
 	prefix string // The prefix under which the data is to be stored
+
+	ctx context.Context // Not exported, every node has its own context.Context
 }
 
 // NewCtx returns a new empty TxnCtx with no parent, no associated data and the default logger.
@@ -60,6 +66,7 @@ func (c *Tctx) NewCtx() *Tctx {
 		log:       c.log,
 		logFields: c.logFields,
 		prefix:    c.prefix,
+		ctx:       c.ctx,
 	}
 }
 
@@ -80,6 +87,14 @@ func (c *Tctx) WithPrefix(prefix string) *Tctx {
 	return n
 }
 
+// WithContext returns a new context with the context.Context set
+func (c *Tctx) WithContext(ctx context.Context) *Tctx {
+	n := c.NewCtx()
+	n.ctx = ctx
+
+	return n
+}
+
 // Set attaches the given key-value pair to the context.
 // If the key exists, the value will be updated.
 func (c *Tctx) Set(key string, value interface{}) error {
@@ -165,6 +180,27 @@ func (c *Tctx) Prefix() string {
 	return c.prefix
 }
 
+// Context returns the context.Context of the transaction. If none has been
+// set, an empty context.Context which is never cancelled is returned.
+func (c *Tctx) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// withContext returns a copy of the given TxnCtx with the context.Context set.
+// TxnCtx implementations other than Tctx and InlineCtx are returned as is.
+func withContext(c TxnCtx, ctx context.Context) TxnCtx {
+	switch tc := c.(type) {
+	case *Tctx:
+		return tc.WithContext(ctx)
+	case *InlineCtx:
+		return tc.WithContext(ctx)
+	}
+	return c
+}
+
 // Implementing the JSON Marshaler and Unmarshaler interfaces to allow Contexts
 // to be exported Using an temporary struct to allow Context to be serialized
 // using JSON.  Cannot serialize Context.Log otherwise.
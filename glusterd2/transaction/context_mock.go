@@ -1,7 +1,9 @@
 package transaction
 
 import (
+	"context"
 	"errors"
+
 	"github.com/pborman/uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -60,3 +62,8 @@ func (m *MockTctx) Logger() log.FieldLogger {
 func (m MockTctx) Prefix() string {
 	return "mock"
 }
+
+// Context returns an empty context.Context
+func (m *MockTctx) Context() context.Context {
+	return context.Background()
+}
@@ -0,0 +1,230 @@
+package transaction
+
+// This file implements the transaction history, a bounded record of the
+// transactions run in the cluster, which is kept in the store after the
+// transactions are cleaned up.
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const historyPrefix = store.GlusterPrefix + "txnhistory/"
+
+var (
+	// historyMaxEntries is the maximum number of transactions kept in the
+	// history. The oldest transactions are removed first.
+	historyMaxEntries int64 = 1000
+	// historyPruneInterval is the interval at which the oldest entries are
+	// removed from the history, so that saving entries stays cheap
+	historyPruneInterval = time.Minute
+)
+
+// HistoryEntry is the record of a transaction in the transaction history
+type HistoryEntry struct {
+	ID         uuid.UUID `json:"id"`
+	Originator uuid.UUID `json:"originator"`
+	// User is the user who sent the request that started the transaction,
+	// as given by the `iss` claim of the request's JWT token
+	User string `json:"user,omitempty"`
+	// Resources are the keys locked by the transaction, for example
+	// "cluster/volumes/<volname>"
+	Resources []string      `json:"resources,omitempty"`
+	Steps     []JobStep     `json:"steps"`
+	Nodes     []uuid.UUID   `json:"nodes"`
+	DryRun    bool          `json:"dry-run,omitempty"`
+	Success   bool          `json:"success"`
+	Error     string        `json:"error,omitempty"`
+	Started   time.Time     `json:"started"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// HistoryFilter selects entries from the transaction history. Empty fields
+// match all entries.
+type HistoryFilter struct {
+	User       string
+	Originator uuid.UUID
+	Resource   string
+	Node       uuid.UUID
+	// Success selects successful transactions if true, and failed
+	// transactions if false
+	Success *bool
+	Since   time.Time
+	Until   time.Time
+	// Limit is the maximum number of entries returned
+	Limit int
+}
+
+// Match returns true if the entry is selected by the filter
+func (f *HistoryFilter) Match(e *HistoryEntry) bool {
+	if f.User != "" && f.User != e.User {
+		return false
+	}
+	if f.Originator != nil && !uuid.Equal(f.Originator, e.Originator) {
+		return false
+	}
+	if f.Resource != "" && !containsResource(e.Resources, f.Resource) {
+		return false
+	}
+	if f.Node != nil && !containsNode(e.Nodes, f.Node) {
+		return false
+	}
+	if f.Success != nil && *f.Success != e.Success {
+		return false
+	}
+	if !f.Since.IsZero() && e.Started.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Started.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// containsResource returns true if the list of lock keys contains the given
+// resource, which may be a full lock key or its last element, like a volume name
+func containsResource(list []string, resource string) bool {
+	for _, l := range list {
+		if l == resource || strings.HasSuffix(l, "/"+resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsNode(list []uuid.UUID, node uuid.UUID) bool {
+	for _, n := range list {
+		if uuid.Equal(n, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// historyEntry returns the history entry for the transaction, which was
+// started at the given time and ended with the given error
+func (t *Txn) historyEntry(started time.Time, err error) *HistoryEntry {
+	e := &HistoryEntry{
+		ID:         t.ID,
+		Originator: gdctx.MyUUID,
+		User:       t.User,
+		Resources:  t.resources(),
+		Steps:      jobSteps(t.Steps, t.Results, false),
+		Nodes:      t.Nodes,
+		DryRun:     t.DryRun,
+		Success:    err == nil,
+		Started:    started,
+		Duration:   time.Since(started),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// resources returns the keys locked by the transaction
+func (t *Txn) resources() []string {
+	var resources []string
+	for _, s := range t.Steps {
+		if key, ok := lockStepKey(s); ok {
+			resources = append(resources, key)
+		}
+	}
+	return resources
+}
+
+// saveHistory adds the transaction to the transaction history. The oldest
+// entries are removed by PruneHistory.
+func (t *Txn) saveHistory(started time.Time, txnErr error) {
+	logger := t.Ctx.Logger()
+
+	b, err := json.Marshal(t.historyEntry(started, txnErr))
+	if err != nil {
+		logger.WithError(err).Warn("failed to marshal transaction history entry")
+		return
+	}
+
+	if _, err := store.Store.Put(context.TODO(), historyPrefix+t.ID.String(), string(b)); err != nil {
+		logger.WithError(err).Warn("failed to save transaction history entry")
+	}
+}
+
+// PruneHistory periodically removes the oldest entries of the transaction
+// history beyond historyMaxEntries, until ctx is cancelled. Every node prunes
+// the history, removing the same entries is harmless.
+func PruneHistory(ctx context.Context) {
+	ticker := time.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := pruneHistory(ctx); err != nil && ctx.Err() == nil {
+			log.WithError(err).Warn("failed to prune transaction history")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pruneHistory removes the oldest entries of the transaction history beyond
+// historyMaxEntries
+func pruneHistory(ctx context.Context) error {
+	resp, err := store.Store.Get(ctx, historyPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil || resp.Count <= historyMaxEntries {
+		return err
+	}
+
+	resp, err = store.Store.Get(ctx, historyPrefix,
+		clientv3.WithPrefix(),
+		clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend),
+		clientv3.WithLimit(resp.Count-historyMaxEntries))
+	if err != nil {
+		return err
+	}
+	for _, kv := range resp.Kvs {
+		if _, err := store.Store.Delete(ctx, string(kv.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHistory returns the entries of the transaction history selected by the
+// filter, most recent first
+func GetHistory(f *HistoryFilter) ([]*HistoryEntry, error) {
+	resp, err := store.Store.Get(context.TODO(), historyPrefix,
+		clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortDescend))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*HistoryEntry, 0)
+	for _, kv := range resp.Kvs {
+		var e HistoryEntry
+		if err := json.Unmarshal(kv.Value, &e); err != nil {
+			return nil, err
+		}
+		if !f.Match(&e) {
+			continue
+		}
+		entries = append(entries, &e)
+		if f.Limit > 0 && len(entries) == f.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
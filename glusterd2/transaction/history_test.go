@@ -0,0 +1,70 @@
+package transaction
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHistoryFilterMatch validates selecting transaction history entries
+func TestHistoryFilterMatch(t *testing.T) {
+	node := uuid.NewRandom()
+	e := &HistoryEntry{
+		ID:         uuid.NewRandom(),
+		Originator: node,
+		User:       "glustercli",
+		Resources:  []string{VolumeLockKey("vol1")},
+		Nodes:      []uuid.UUID{node},
+		Success:    true,
+		Started:    time.Now(),
+	}
+
+	assert.True(t, (&HistoryFilter{}).Match(e))
+	assert.True(t, (&HistoryFilter{User: "glustercli", Resource: "vol1", Node: node}).Match(e))
+	assert.False(t, (&HistoryFilter{User: "admin"}).Match(e))
+	assert.False(t, (&HistoryFilter{Resource: "vol2"}).Match(e))
+	assert.False(t, (&HistoryFilter{Originator: uuid.NewRandom()}).Match(e))
+
+	failed := false
+	assert.False(t, (&HistoryFilter{Success: &failed}).Match(e))
+
+	assert.True(t, (&HistoryFilter{Since: e.Started.Add(-time.Minute)}).Match(e))
+	assert.False(t, (&HistoryFilter{Since: e.Started.Add(time.Minute)}).Match(e))
+	assert.False(t, (&HistoryFilter{Until: e.Started.Add(-time.Minute)}).Match(e))
+}
+
+// TestPruneHistory validates that the oldest history entries beyond the limit
+// are removed
+func TestPruneHistory(t *testing.T) {
+	defer initTestStore(t)()
+
+	defer func(n int64) {
+		historyMaxEntries = n
+	}(historyMaxEntries)
+	historyMaxEntries = 2
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id := uuid.New()
+		ids = append(ids, id)
+		_, err := store.Store.Put(context.Background(), historyPrefix+id, "{}")
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, pruneHistory(context.Background()))
+	resp, err := store.Store.Get(context.Background(), historyPrefix,
+		clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend))
+	assert.Nil(t, err)
+	var kept []string
+	for _, kv := range resp.Kvs {
+		kept = append(kept, strings.TrimPrefix(string(kv.Key), historyPrefix))
+	}
+	assert.Equal(t, ids[2:], kept)
+}
@@ -0,0 +1,200 @@
+package transaction
+
+// This file implements InlineCtx, a TxnCtx which keeps its data in memory
+// instead of in the store. The data is sent to remote nodes along with the
+// steps they run, and the changes made by the steps are sent back in the
+// responses, so that running a step doesn't require any store operations.
+// The data is only saved in the store as part of the transaction journal,
+// which is needed to recover the transaction.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrKeyNotFound is returned by InlineCtx.Get if the key is not present
+var ErrKeyNotFound = errors.New("key not found in transaction context")
+
+// inlineData is the data of an InlineCtx. It is shared by an InlineCtx and the
+// contexts derived from it.
+type inlineData struct {
+	sync.RWMutex
+	values map[string][]byte
+	// changed holds the keys set or deleted since the data was created
+	changed map[string]bool
+}
+
+func newInlineData(values map[string][]byte) *inlineData {
+	if values == nil {
+		values = make(map[string][]byte)
+	}
+	return &inlineData{
+		values:  values,
+		changed: make(map[string]bool),
+	}
+}
+
+// InlineCtx is a TxnCtx which carries its data along with the transaction
+// steps instead of storing it in the store
+type InlineCtx struct {
+	*Tctx
+	data *inlineData
+}
+
+// NewInlineCtx returns a new InlineCtx with no data, using the logger, prefix
+// and context.Context of the given Tctx
+func NewInlineCtx(c *Tctx) *InlineCtx {
+	return &InlineCtx{
+		Tctx: c,
+		data: newInlineData(nil),
+	}
+}
+
+// WithContext returns a new context with the context.Context set. The new
+// context shares the data of c.
+func (c *InlineCtx) WithContext(ctx context.Context) *InlineCtx {
+	return &InlineCtx{
+		Tctx: c.Tctx.WithContext(ctx),
+		data: c.data,
+	}
+}
+
+// Set attaches the given key-value pair to the context.
+// If the key exists, the value will be updated.
+func (c *InlineCtx) Set(key string, value interface{}) error {
+	b, e := json.Marshal(value)
+	if e != nil {
+		c.log.WithFields(log.Fields{
+			"error": e,
+			"key":   key,
+		}).Error("failed to marshal value")
+		return e
+	}
+
+	c.data.Lock()
+	defer c.data.Unlock()
+	c.data.values[key] = b
+	c.data.changed[key] = true
+	return nil
+}
+
+// SetNodeResult is similar to Set but prefixes the key with the node UUID
+// specified. This function can be used by nodes to store results of
+// transaction steps.
+func (c *InlineCtx) SetNodeResult(nodeID uuid.UUID, key string, value interface{}) error {
+	return c.Set(nodeID.String()+"/"+key, value)
+}
+
+// Get gets the value for the given key if available.
+// Returns ErrKeyNotFound if not found.
+func (c *InlineCtx) Get(key string, value interface{}) error {
+	c.data.RLock()
+	b, ok := c.data.values[key]
+	c.data.RUnlock()
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	e := json.Unmarshal(b, value)
+	if e != nil {
+		c.log.WithFields(log.Fields{
+			"error": e,
+			"key":   key,
+		}).Error("failed to unmarshal value")
+	}
+	return e
+}
+
+// GetNodeResult is similar to Get but prefixes the key with node UUID
+// specified. This function can be used by the transaction initiator node to
+// fetch results of transaction step run on remote nodes.
+func (c *InlineCtx) GetNodeResult(nodeID uuid.UUID, key string, value interface{}) error {
+	return c.Get(nodeID.String()+"/"+key, value)
+}
+
+// Delete deletes the key and attached value
+func (c *InlineCtx) Delete(key string) error {
+	c.data.Lock()
+	defer c.data.Unlock()
+	delete(c.data.values, key)
+	c.data.changed[key] = true
+	return nil
+}
+
+// values returns a copy of the data of the context
+func (c *InlineCtx) values() map[string][]byte {
+	c.data.RLock()
+	defer c.data.RUnlock()
+
+	values := make(map[string][]byte, len(c.data.values))
+	for k, v := range c.data.values {
+		values[k] = v
+	}
+	return values
+}
+
+// changes returns the keys set and the keys deleted since the context was
+// created
+func (c *InlineCtx) changes() (map[string][]byte, []string) {
+	c.data.RLock()
+	defer c.data.RUnlock()
+
+	set := make(map[string][]byte)
+	var deleted []string
+	for k := range c.data.changed {
+		if v, ok := c.data.values[k]; ok {
+			set[k] = v
+		} else {
+			deleted = append(deleted, k)
+		}
+	}
+	return set, deleted
+}
+
+// apply applies the changes made to the data of a context on another node
+func (c *InlineCtx) apply(set map[string][]byte, deleted []string) {
+	c.data.Lock()
+	defer c.data.Unlock()
+
+	for k, v := range set {
+		c.data.values[k] = v
+		c.data.changed[k] = true
+	}
+	for _, k := range deleted {
+		delete(c.data.values, k)
+		c.data.changed[k] = true
+	}
+}
+
+type expInlineContext struct {
+	Tctx *Tctx
+	Data map[string][]byte
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (c *InlineCtx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(expInlineContext{
+		Tctx: c.Tctx,
+		Data: c.values(),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (c *InlineCtx) UnmarshalJSON(d []byte) error {
+	var ac expInlineContext
+	if e := json.Unmarshal(d, &ac); e != nil {
+		return e
+	}
+
+	c.Tctx = ac.Tctx
+	if c.Tctx == nil {
+		c.Tctx = NewCtx()
+	}
+	c.data = newInlineData(ac.Data)
+	return nil
+}
@@ -0,0 +1,217 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInlineCtx validates setting and getting data in an InlineCtx
+func TestInlineCtx(t *testing.T) {
+	c := NewInlineCtx(NewCtx())
+	node := uuid.NewRandom()
+
+	var v string
+	assert.Equal(t, ErrKeyNotFound, c.Get("key", &v))
+
+	assert.Nil(t, c.Set("key", "value"))
+	assert.Nil(t, c.SetNodeResult(node, "key", "result"))
+	assert.Nil(t, c.Get("key", &v))
+	assert.Equal(t, "value", v)
+	assert.Nil(t, c.GetNodeResult(node, "key", &v))
+	assert.Equal(t, "result", v)
+
+	// Contexts derived from c share its data
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Nil(t, c.WithContext(ctx).Delete("key"))
+	assert.Equal(t, ErrKeyNotFound, c.Get("key", &v))
+
+	b, err := json.Marshal(c)
+	assert.Nil(t, err)
+	u := new(InlineCtx)
+	assert.Nil(t, json.Unmarshal(b, u))
+	assert.Nil(t, u.GetNodeResult(node, "key", &v))
+	assert.Equal(t, "result", v)
+}
+
+// TestInlineCtxRunStep validates that the changes made by a remote step are
+// sent back to the originator of the transaction
+func TestInlineCtxRunStep(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+
+	RegisterStepFunc(func(c TxnCtx) error {
+		var v string
+		if err := c.Get("in", &v); err != nil {
+			return err
+		}
+		if err := c.Delete("gone"); err != nil {
+			return err
+		}
+		return c.SetNodeResult(gdctx.MyUUID, "out", v)
+	}, "test-inline.Step")
+
+	c := NewInlineCtx(NewCtx())
+	c.Set("in", "value")
+	c.Set("gone", "value")
+
+	req, err := newTxnStepReq("test-inline.Step", c)
+	assert.Nil(t, err)
+	assert.True(t, req.Inline)
+
+	rsp, err := new(txnSvc).RunStep(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Empty(t, rsp.Error)
+	assert.Len(t, rsp.Data, 1)
+	assert.Equal(t, []string{"gone"}, rsp.Deleted)
+
+	_, err = applyTxnStepResp(c, rsp)
+	assert.Nil(t, err)
+
+	var v string
+	assert.Nil(t, c.GetNodeResult(gdctx.MyUUID, "out", &v))
+	assert.Equal(t, "value", v)
+	assert.Equal(t, ErrKeyNotFound, c.Get("gone", &v))
+}
+
+// benchStoreLatency is the simulated latency of a store operation, which is
+// a round trip to the store leader and usually a write to the disks of a
+// quorum of store members
+const benchStoreLatency = 500 * time.Microsecond
+
+// countingKV is an in-memory clientv3.KV which counts the operations done and
+// simulates the latency of the store. Only Put, Get and Delete of single keys,
+// as done by Tctx, are implemented.
+type countingKV struct {
+	clientv3.KV
+
+	sync.Mutex
+	data map[string]string
+	ops  int
+}
+
+func (kv *countingKV) op() {
+	time.Sleep(benchStoreLatency)
+	kv.ops++
+}
+
+func (kv *countingKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	kv.Lock()
+	defer kv.Unlock()
+	kv.op()
+	kv.data[key] = val
+	return &clientv3.PutResponse{}, nil
+}
+
+func (kv *countingKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.Lock()
+	defer kv.Unlock()
+	kv.op()
+	resp := &clientv3.GetResponse{}
+	if v, ok := kv.data[key]; ok {
+		resp.Kvs = []*mvccpb.KeyValue{{Key: []byte(key), Value: []byte(v)}}
+		resp.Count = 1
+	}
+	return resp, nil
+}
+
+func (kv *countingKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	kv.Lock()
+	defer kv.Unlock()
+	kv.op()
+	delete(kv.data, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+// benchmarkRemoteStep runs a step, similar to the volume status step, on the
+// given number of nodes with contexts returned by newCtx. The remote nodes are
+// simulated by calling the TxnSvc directly.
+func benchmarkRemoteStep(b *testing.B, nodes int, newCtx func() TxnCtx) {
+	kv := &countingKV{data: make(map[string]string)}
+	oldStore, oldUUID := store.Store, gdctx.MyUUID
+	store.Store = &store.GDStore{Client: &clientv3.Client{KV: kv}}
+	defer func() {
+		store.Store, gdctx.MyUUID = oldStore, oldUUID
+	}()
+
+	RegisterStepFunc(func(c TxnCtx) error {
+		var volname string
+		if err := c.Get("volname", &volname); err != nil {
+			return err
+		}
+		return c.SetNodeResult(gdctx.MyUUID, "status", volname+" is started")
+	}, "bench-step.Status")
+
+	ids := make([]uuid.UUID, nodes)
+	for i := range ids {
+		ids[i] = uuid.NewRandom()
+	}
+	svc := new(txnSvc)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		c := newCtx()
+		if err := c.Set("volname", "vol1"); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, id := range ids {
+			gdctx.MyUUID = id
+			req, err := newTxnStepReq("bench-step.Status", c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			rsp, err := svc.RunStep(context.Background(), req)
+			if err != nil || rsp.Error != "" {
+				b.Fatal(err, rsp.Error)
+			}
+			if _, err := applyTxnStepResp(c, rsp); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		var status string
+		for _, id := range ids {
+			if err := c.GetNodeResult(id, "status", &status); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.StopTimer()
+
+	b.Logf("%d nodes: %.1f store operations per transaction step", nodes, float64(kv.ops)/float64(b.N))
+}
+
+func BenchmarkTctxRemoteStep3Nodes(b *testing.B) {
+	benchmarkRemoteStep(b, 3, func() TxnCtx {
+		return NewCtx().WithPrefix(txnPrefix + uuid.New())
+	})
+}
+
+func BenchmarkInlineCtxRemoteStep3Nodes(b *testing.B) {
+	benchmarkRemoteStep(b, 3, func() TxnCtx {
+		return NewInlineCtx(NewCtx().WithPrefix(txnPrefix + uuid.New()))
+	})
+}
+
+func BenchmarkTctxRemoteStep9Nodes(b *testing.B) {
+	benchmarkRemoteStep(b, 9, func() TxnCtx {
+		return NewCtx().WithPrefix(txnPrefix + uuid.New())
+	})
+}
+
+func BenchmarkInlineCtxRemoteStep9Nodes(b *testing.B) {
+	benchmarkRemoteStep(b, 9, func() TxnCtx {
+		return NewInlineCtx(NewCtx().WithPrefix(txnPrefix + uuid.New()))
+	})
+}
@@ -0,0 +1,223 @@
+package transaction
+
+// This file implements jobs, which track the progress of transactions run
+// asynchronously with Txn.DoAsync(). Jobs are saved in the store, so that the
+// progress can be queried from any node.
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/errors"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+)
+
+const (
+	jobPrefix = store.GlusterPrefix + "jobs/"
+	// jobTTL is the time for which a job is kept in the store after it ends
+	jobTTL = 24 * 60 * 60 // seconds
+)
+
+// JobState is the state of a Job or of one of its steps
+type JobState string
+
+const (
+	// JobPending is the state of a step that has not been run yet
+	JobPending JobState = "pending"
+	// JobRunning is the state of a job or a step that is running
+	JobRunning JobState = "running"
+	// JobSucceeded is the state of a job or a step that completed successfully
+	JobSucceeded JobState = "succeeded"
+	// JobFailed is the state of a job or a step that failed
+	JobFailed JobState = "failed"
+)
+
+// JobStep is the progress of a single step of a Job
+type JobStep struct {
+	StepFunc string      `json:"step"`
+	State    JobState    `json:"state"`
+	Result   *StepResult `json:"result,omitempty"`
+}
+
+// Job is the record of a transaction run asynchronously
+type Job struct {
+	ID         uuid.UUID       `json:"id"`
+	Originator uuid.UUID       `json:"originator"`
+	State      JobState        `json:"state"`
+	Steps      []JobStep       `json:"steps"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Created    time.Time       `json:"created"`
+	Updated    time.Time       `json:"updated"`
+}
+
+// JobDoneFunc is called with the results of Txn.Do() when an asynchronous
+// transaction completes. The value it returns is saved as the result of the
+// job, and the error it returns marks the job as failed.
+type JobDoneFunc func(TxnCtx, error) (interface{}, error)
+
+// DoAsync runs the transaction in the background and returns a Job which
+// tracks its progress. The ID of the job is the ID of the transaction. done is
+// called once the transaction completes, and the transaction is cleaned up
+// after that; Cleanup() is a no-op for transactions run with DoAsync.
+func (t *Txn) DoAsync(done JobDoneFunc) (*Job, error) {
+	now := time.Now()
+	j := &Job{
+		ID:         t.ID,
+		Originator: gdctx.MyUUID,
+		State:      JobRunning,
+		Created:    now,
+		Updated:    now,
+	}
+	j.Steps = jobSteps(t.Steps, nil, true)
+
+	if err := saveJob(j, 0); err != nil {
+		return nil, err
+	}
+	t.job = j
+
+	// The transaction outlives the request which started it
+	t.Ctx = withContext(t.Ctx, context.Background())
+
+	go func() {
+		defer t.cleanup()
+
+		c, err := t.Do()
+
+		var result interface{}
+		if done != nil {
+			result, err = done(c, err)
+		}
+
+		t.finishJob(result, err)
+	}()
+
+	return j, nil
+}
+
+// jobSteps returns the progress of the given steps from their results. If
+// running is set, the first step without a result is marked as running.
+func jobSteps(steps []*Step, results []*StepResult, running bool) []JobStep {
+	jsteps := make([]JobStep, len(steps))
+	for i, s := range steps {
+		jsteps[i] = JobStep{StepFunc: s.DoFunc, State: JobPending}
+		if i >= len(results) || results[i] == nil {
+			if running {
+				jsteps[i].State = JobRunning
+				running = false
+			}
+			continue
+		}
+		jsteps[i].Result = results[i]
+		if results[i].Err() != nil {
+			jsteps[i].State = JobFailed
+			running = false
+		} else {
+			jsteps[i].State = JobSucceeded
+		}
+	}
+	return jsteps
+}
+
+// updateJob records the progress of the transaction steps in its job, if the
+// transaction is being run asynchronously.
+func (t *Txn) updateJob() {
+	if t.job == nil {
+		return
+	}
+
+	t.job.Steps = jobSteps(t.Steps, t.Results, true)
+	t.job.Updated = time.Now()
+	if err := saveJob(t.job, 0); err != nil {
+		t.Ctx.Logger().WithError(err).Warn("failed to update job")
+	}
+}
+
+// finishJob records the final state and result of the transaction in its job
+func (t *Txn) finishJob(result interface{}, err error) {
+	j := t.job
+	j.Steps = jobSteps(t.Steps, t.Results, false)
+	j.Updated = time.Now()
+
+	if err != nil {
+		j.State = JobFailed
+		j.Error = err.Error()
+	} else {
+		j.State = JobSucceeded
+		if result != nil {
+			b, err := json.Marshal(result)
+			if err != nil {
+				t.Ctx.Logger().WithError(err).Error("failed to marshal job result")
+			}
+			j.Result = b
+		}
+	}
+
+	if err := saveJob(j, jobTTL); err != nil {
+		t.Ctx.Logger().WithError(err).Error("failed to save job")
+	}
+}
+
+// saveJob saves the job in the store. If ttl is non-zero, the job is deleted
+// from the store after ttl seconds.
+func saveJob(j *Job, ttl int64) error {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	var opts []clientv3.OpOption
+	if ttl != 0 {
+		lease, err := store.Store.Grant(context.TODO(), ttl)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, clientv3.WithLease(lease.ID))
+	}
+
+	_, err = store.Store.Put(context.TODO(), jobPrefix+j.ID.String(), string(b), opts...)
+	return err
+}
+
+// GetJob returns the job with the given ID from the store
+func GetJob(id string) (*Job, error) {
+	resp, err := store.Store.Get(context.TODO(), jobPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Count != 1 {
+		return nil, errors.ErrJobNotFound
+	}
+
+	var j Job
+	if err := json.Unmarshal(resp.Kvs[0].Value, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// finishRecoveredJob marks the job of a recovered transaction, if there is
+// one, as finished.
+func finishRecoveredJob(t *Txn, err error) {
+	j, e := GetJob(t.ID.String())
+	if e != nil || j.State != JobRunning {
+		return
+	}
+
+	if err == nil {
+		err = errors.ErrJobInterrupted
+	}
+	j.State = JobFailed
+	j.Error = err.Error()
+	j.Updated = time.Now()
+
+	if err := saveJob(j, jobTTL); err != nil {
+		t.Ctx.Logger().WithError(err).Error("failed to save job")
+	}
+}
@@ -0,0 +1,69 @@
+package transaction
+
+import (
+	"context"
+	"expvar"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestJobSteps validates the progress of steps computed from their results
+func TestJobSteps(t *testing.T) {
+	node := uuid.NewRandom()
+	steps := []*Step{
+		{DoFunc: "test-job.First"},
+		{DoFunc: "test-job.Second"},
+		{DoFunc: "test-job.Third"},
+	}
+	succeeded := &StepResult{StepFunc: "test-job.First", Nodes: []NodeResult{{NodeID: node, Success: true}}}
+	failed := &StepResult{StepFunc: "test-job.Second", Nodes: []NodeResult{{NodeID: node, Success: false}}}
+
+	jsteps := jobSteps(steps, nil, true)
+	assert.Equal(t, JobRunning, jsteps[0].State)
+	assert.Equal(t, JobPending, jsteps[1].State)
+	assert.Equal(t, JobPending, jsteps[2].State)
+
+	jsteps = jobSteps(steps, []*StepResult{succeeded, nil, nil}, true)
+	assert.Equal(t, JobSucceeded, jsteps[0].State)
+	assert.Equal(t, succeeded, jsteps[0].Result)
+	assert.Equal(t, JobRunning, jsteps[1].State)
+	assert.Equal(t, JobPending, jsteps[2].State)
+
+	jsteps = jobSteps(steps, []*StepResult{succeeded, failed, nil}, true)
+	assert.Equal(t, JobSucceeded, jsteps[0].State)
+	assert.Equal(t, JobFailed, jsteps[1].State)
+	assert.Equal(t, JobPending, jsteps[2].State)
+}
+
+func txnsInProgress() int64 {
+	if v, ok := expTxn.Get("initiated_txn_in_progress").(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// TestDoAsyncFailsEarly validates that transactions failing before their
+// steps are run are not counted as in progress
+func TestDoAsyncFailsEarly(t *testing.T) {
+	defer initTestStore(t)()
+
+	before := txnsInProgress()
+
+	txn := NewTxn(context.WithValue(context.Background(), gdctx.ReqIDKey, uuid.New()))
+	// Steps can only depend on the steps before them
+	txn.Steps = []*Step{{DoFunc: "test-job.First", Parallel: true, DependsOn: []int{0}}}
+
+	done := make(chan error)
+	_, err := txn.DoAsync(func(c TxnCtx, err error) (interface{}, error) {
+		done <- err
+		return nil, err
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, <-done)
+
+	assert.Equal(t, before, txnsInProgress())
+}
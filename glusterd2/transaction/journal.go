@@ -0,0 +1,265 @@
+package transaction
+
+// This file implements the transaction journal, which records the progress of
+// transactions in the store so that they can be recovered if the originator
+// crashes before the transaction is complete.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	journalPrefix = store.GlusterPrefix + "txnjournal/"
+	// recoveryLockTimeout is the time allowed to obtain the locks of a
+	// recovered transaction, which may wait for the transactions
+	// started by other nodes meanwhile
+	recoveryLockTimeout = 2 * time.Minute
+)
+
+// RecoveryPolicy determines how an incomplete transaction is recovered when
+// its originator restarts
+type RecoveryPolicy int
+
+const (
+	// RecoverRollback undoes the steps of the transaction that were run
+	RecoverRollback RecoveryPolicy = iota
+	// RecoverRollForward runs the remaining steps of the transaction,
+	// starting with the step that was running when the originator stopped.
+	// This must only be used if the DoFuncs of the transaction are
+	// idempotent.
+	RecoverRollForward
+)
+
+// journalState is the state of a journaled transaction
+type journalState int
+
+const (
+	// journalRunning is set while the steps of the transaction are running
+	journalRunning journalState = iota
+	// journalRollingBack is set when a step has failed and the transaction
+	// is being rolled back
+	journalRollingBack
+	// journalDone is set when the transaction has finished running, either
+	// successfully or after being rolled back, and only needs cleaning up
+	journalDone
+)
+
+// journal is the record of a transaction's progress saved in the store
+type journal struct {
+	ID         uuid.UUID
+	Originator uuid.UUID
+	Ctx        json.RawMessage
+	Steps      []*Step
+	Nodes      []uuid.UUID
+	Recovery   RecoveryPolicy
+	State      journalState
+	// StepsDone is the number of steps that completed successfully before
+	// the group of steps being run, see stepGroupEnd()
+	StepsDone int
+	// Results are the per-node results of the steps, indexed by step
+	Results []*StepResult
+	// InlineCtx is set if Ctx is an InlineCtx, which includes its data
+	InlineCtx bool
+}
+
+func journalKey(id uuid.UUID) string {
+	return journalPrefix + id.String()
+}
+
+// saveJournal records the progress of the transaction in the store
+func (t *Txn) saveJournal(stepsDone int, state journalState) error {
+	c, err := json.Marshal(t.Ctx)
+	if err != nil {
+		return err
+	}
+	_, inline := t.Ctx.(*InlineCtx)
+
+	j := journal{
+		ID:         t.ID,
+		Originator: gdctx.MyUUID,
+		Ctx:        c,
+		Steps:      t.Steps,
+		Nodes:      t.Nodes,
+		Recovery:   t.Recovery,
+		State:      state,
+		StepsDone:  stepsDone,
+		Results:    t.Results,
+		InlineCtx:  inline,
+	}
+	b, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.Store.Put(context.TODO(), journalKey(t.ID), string(b))
+	return err
+}
+
+// updateJournal is saveJournal for use once the transaction steps have
+// started running. Failures are only logged, as the transaction cannot be
+// stopped at this point.
+func (t *Txn) updateJournal(stepsDone int, state journalState) {
+	if err := t.saveJournal(stepsDone, state); err != nil {
+		t.Ctx.Logger().WithError(err).Warn("failed to update transaction journal")
+	}
+}
+
+func (t *Txn) deleteJournal() {
+	store.Store.Delete(context.TODO(), journalKey(t.ID))
+}
+
+// RecoverTransactions finds transactions originated by this node that were
+// left incomplete in the store, and recovers them according to their
+// RecoveryPolicy. It must be called after all StepFuncs have been registered.
+func RecoverTransactions() {
+	resp, err := store.Store.Get(context.TODO(), journalPrefix, clientv3.WithPrefix())
+	if err != nil {
+		log.WithError(err).Error("failed to get transaction journals from store")
+		return
+	}
+
+	for _, kv := range resp.Kvs {
+		var j journal
+		if err := json.Unmarshal(kv.Value, &j); err != nil {
+			log.WithError(err).WithField("key", string(kv.Key)).Error("failed to unmarshal transaction journal")
+			continue
+		}
+
+		if !uuid.Equal(j.Originator, gdctx.MyUUID) {
+			continue
+		}
+
+		if err := recoverTxn(&j); err != nil {
+			log.WithError(err).WithField("reqid", j.ID.String()).Error("failed to recover transaction")
+		}
+	}
+}
+
+func recoverTxn(j *journal) (err error) {
+	var c TxnCtx = new(Tctx)
+	if j.InlineCtx {
+		c = new(InlineCtx)
+	}
+	if err := json.Unmarshal(j.Ctx, c); err != nil {
+		return err
+	}
+
+	t := &Txn{
+		ID:       j.ID,
+		Ctx:      c,
+		Steps:    j.Steps,
+		Results:  make([]*StepResult, len(j.Steps)),
+		Nodes:    j.Nodes,
+		Recovery: j.Recovery,
+	}
+	copy(t.Results, j.Results)
+
+	stepsDone := j.StepsDone
+	logger := t.Ctx.Logger().WithField("stepsdone", stepsDone)
+
+	if j.State == journalDone || stepsDone >= len(t.Steps) {
+		logger.Info("found completed transaction in journal, cleaning up")
+		t.cleanup()
+		return nil
+	}
+
+	// The locks of the transaction were held with the session of the
+	// previous instance of this node, and are released when its lease
+	// expires. They are obtained again before recovering, so that no other
+	// transaction runs on the same resources meanwhile. If they can't be
+	// obtained, the journal is kept to recover the transaction later.
+	locks, err := t.relock(stepsDone)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, l := range locks {
+			l.Unlock(context.Background())
+		}
+	}()
+
+	defer t.cleanup()
+	defer func() {
+		finishRecoveredJob(t, err)
+	}()
+
+	if j.Recovery == RecoverRollForward && j.State == journalRunning {
+		logger.Info("found incomplete transaction in journal, rolling forward")
+		expTxn.Add("initiated_txn_in_progress", 1)
+		defer expTxn.Add("initiated_txn_in_progress", -1)
+
+		_, err := t.do(stepsDone)
+		return err
+	}
+
+	logger.Info("found incomplete transaction in journal, rolling back")
+	// All the steps of the group being run may have been started
+	if failures := t.undo(t.stepGroupEnd(stepsDone) - 1); len(failures) != 0 {
+		return fmt.Errorf("failed to undo %d step(s)", len(failures))
+	}
+
+	return nil
+}
+
+// relock registers the lock StepFuncs of the recovered transaction, which are
+// registered on demand, and obtains the locks which may have been held when
+// the group of steps starting at stepsDone was being run. The obtained locks
+// are returned.
+func (t *Txn) relock(stepsDone int) ([]*rwLock, error) {
+	held := make(map[string]*StepLock)
+	var keys []string
+	end := t.stepGroupEnd(stepsDone)
+	for i, s := range t.Steps {
+		l := s.Lock
+		if l == nil {
+			continue
+		}
+		if _, _, err := createLockStepFunc(l.Key, l.Mode); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case i >= end:
+		case !l.Unlock:
+			if _, ok := held[l.Key]; !ok {
+				keys = append(keys, l.Key)
+			}
+			held[l.Key] = l
+		case i < stepsDone:
+			// The unlock step may have run if it is in the group
+			// being run, so the lock is only known to be released
+			// if it is done
+			delete(held, l.Key)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryLockTimeout)
+	defer cancel()
+
+	var locks []*rwLock
+	for _, key := range keys {
+		sl, ok := held[key]
+		if !ok {
+			continue
+		}
+		l := newRWLock(key, sl.Mode, holderID(t.Ctx))
+		if err := l.Relock(ctx); err != nil {
+			for _, l := range locks {
+				l.Unlock(context.Background())
+			}
+			return nil, fmt.Errorf("failed to obtain lock %s: %s", key, err)
+		}
+		locks = append(locks, l)
+	}
+	return locks, nil
+}
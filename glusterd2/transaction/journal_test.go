@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// recoveryTest is a transaction left incomplete in the journal by a previous
+// instance of this node, which held the lock of the transaction with a lease
+// of its own
+type recoveryTest struct {
+	txn   *Txn
+	lock  *rwLock
+	calls []string
+}
+
+func newRecoveryTest(t *testing.T, recovery RecoveryPolicy) *recoveryTest {
+	r := new(recoveryTest)
+
+	// The StepFuncs record their calls, and fail if the lock of the
+	// transaction isn't held with the current session
+	for _, name := range []string{"First", "Second", "UndoFirst", "UndoSecond"} {
+		name := name
+		RegisterStepFunc(func(TxnCtx) error {
+			r.calls = append(r.calls, name)
+			resp, err := store.Store.Get(context.Background(), r.lock.holderKey())
+			if err != nil {
+				return err
+			}
+			if assert.Len(t, resp.Kvs, 1, "lock not held while recovering") {
+				assert.Equal(t, int64(store.Store.Session().Lease()), resp.Kvs[0].Lease)
+			}
+			return nil
+		}, "test-recover."+name)
+	}
+
+	ctx := context.WithValue(context.Background(), gdctx.ReqIDKey, uuid.New())
+	r.txn = NewTxn(ctx)
+	lock, unlock, err := CreateLockSteps(VolumeLockKey("vol1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []uuid.UUID{gdctx.MyUUID}
+	r.txn.Steps = []*Step{
+		lock,
+		{DoFunc: "test-recover.First", UndoFunc: "test-recover.UndoFirst", Nodes: nodes},
+		{DoFunc: "test-recover.Second", UndoFunc: "test-recover.UndoSecond", Nodes: nodes},
+		unlock,
+	}
+	r.txn.Nodes = nodes
+	r.txn.Recovery = recovery
+
+	// The lock and the first step were done when the previous instance
+	// stopped
+	succeeded := []NodeResult{{NodeID: gdctx.MyUUID, Success: true}}
+	r.txn.Results = []*StepResult{
+		{StepFunc: lock.DoFunc, Nodes: succeeded},
+		{StepFunc: "test-recover.First", Nodes: succeeded},
+		nil,
+		nil,
+	}
+	r.lock = newRWLock(VolumeLockKey("vol1"), LockExclusive, holderID(r.txn.Ctx))
+	lease, err := store.Store.Grant(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Store.Put(context.Background(), r.lock.holderKey(), "", clientv3.WithLease(lease.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.txn.saveJournal(2, journalRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// checkDone checks that the recovered transaction released its lock and
+// removed its journal
+func (r *recoveryTest) checkDone(t *testing.T) {
+	for _, key := range []string{r.lock.holderKey(), journalKey(r.txn.ID)} {
+		resp, err := store.Store.Get(context.Background(), key)
+		assert.Nil(t, err)
+		assert.Empty(t, resp.Kvs, "%s left after recovery", key)
+	}
+}
+
+// TestRecoverRollback validates that the steps of an incomplete transaction
+// are undone, with its lock held
+func TestRecoverRollback(t *testing.T) {
+	defer initTestStore(t)()
+
+	r := newRecoveryTest(t, RecoverRollback)
+	RecoverTransactions()
+
+	// The second step may have been started, so it is undone too
+	assert.Equal(t, []string{"UndoSecond", "UndoFirst"}, r.calls)
+	r.checkDone(t)
+}
+
+// TestRecoverRollForward validates that the remaining steps of an incomplete
+// transaction are run, with its lock held
+func TestRecoverRollForward(t *testing.T) {
+	defer initTestStore(t)()
+
+	r := newRecoveryTest(t, RecoverRollForward)
+	RecoverTransactions()
+
+	assert.Equal(t, []string{"Second"}, r.calls)
+	r.checkDone(t)
+}
@@ -3,29 +3,52 @@ package transaction
 import (
 	"context"
 	"errors"
+	"path"
 	"time"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 	"github.com/gluster/glusterd2/glusterd2/store"
 
-	"github.com/coreos/etcd/clientv3/concurrency"
 	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
 	lockPrefix        = store.GlusterPrefix + "locks/"
 	lockObtainTimeout = 5 * time.Second
+
+	lockFuncSuffix    = ".Lock"
+	unlockFuncSuffix  = ".Unlock"
+	rlockFuncSuffix   = ".RLock"
+	runlockFuncSuffix = ".RUnlock"
 )
 
 // ErrLockTimeout is the error returned when lock could not be obtained
 // and the request timed out
 var ErrLockTimeout = errors.New("could not obtain lock: another conflicting transaction may be in progress")
 
-// createLockStepFunc returns the registry IDs of StepFuncs which lock/unlock the given key.
-// If existing StepFuncs are not found, new funcs are created and registered.
-func createLockStepFunc(key string) (string, string, error) {
-	lockFuncID := key + ".Lock"
-	unlockFuncID := key + ".Unlock"
+// lockFuncSuffixes returns the suffixes of the lock and unlock StepFunc IDs
+// for the given lock mode
+func lockFuncSuffixes(mode LockMode) (string, string) {
+	if mode == LockShared {
+		return rlockFuncSuffix, runlockFuncSuffix
+	}
+	return lockFuncSuffix, unlockFuncSuffix
+}
+
+// holderID returns the ID of the transaction with the given context. Lock
+// StepFuncs are shared by all transactions locking the same key, so the lock
+// holder is looked up from the transaction context.
+func holderID(c TxnCtx) string {
+	return path.Base(c.Prefix())
+}
+
+// createLockStepFunc returns the registry IDs of StepFuncs which lock/unlock the given key
+// in the given mode. If existing StepFuncs are not found, new funcs are created and registered.
+func createLockStepFunc(key string, mode LockMode) (string, string, error) {
+	lockSuffix, unlockSuffix := lockFuncSuffixes(mode)
+	lockFuncID := key + lockSuffix
+	unlockFuncID := key + unlockSuffix
 
 	_, lockFuncFound := GetStepFunc(lockFuncID)
 	_, unlockFuncFound := GetStepFunc(unlockFuncID)
@@ -34,22 +57,20 @@ func createLockStepFunc(key string) (string, string, error) {
 		return lockFuncID, unlockFuncID, nil
 	}
 
-	key = lockPrefix + key
-	locker := concurrency.NewMutex(store.Store.Session, key)
-
 	lockFunc := func(c TxnCtx) error {
 
-		ctx, cancel := context.WithTimeout(context.Background(), lockObtainTimeout)
+		ctx, cancel := context.WithTimeout(c.Context(), lockObtainTimeout)
 		defer cancel()
 
-		c.Logger().WithField("key", key).Debug("attempting to lock")
-		err := locker.Lock(ctx)
+		logger := c.Logger().WithFields(log.Fields{"key": key, "mode": mode})
+		logger.Debug("attempting to lock")
+		err := newRWLock(key, mode, holderID(c)).Lock(ctx)
 		switch err {
 		case nil:
-			c.Logger().WithField("key", key).Debug("lock obtained")
+			logger.Debug("lock obtained")
 		case context.DeadlineExceeded:
 			// Propagate this all the way back to the client as a HTTP 409 response
-			c.Logger().WithField("key", key).Debug("timeout: failed to obtain lock")
+			logger.Debug("timeout: failed to obtain lock")
 			err = ErrLockTimeout
 		}
 
@@ -59,10 +80,11 @@ func createLockStepFunc(key string) (string, string, error) {
 
 	unlockFunc := func(c TxnCtx) error {
 
-		c.Logger().WithField("key", key).Debug("attempting to unlock")
-		err := locker.Unlock(context.Background())
+		logger := c.Logger().WithFields(log.Fields{"key": key, "mode": mode})
+		logger.Debug("attempting to unlock")
+		err := newRWLock(key, mode, holderID(c)).Unlock(context.Background())
 		if err == nil {
-			c.Logger().WithField("key", key).Debug("lock unlocked")
+			logger.Debug("lock unlocked")
 		}
 
 		return err
@@ -72,15 +94,52 @@ func createLockStepFunc(key string) (string, string, error) {
 	return lockFuncID, unlockFuncID, nil
 }
 
-// CreateLockSteps returns a lock and an unlock Step which lock/unlock the given key
+// CreateLockSteps returns a lock and an unlock Step which exclusively lock/unlock the given key.
+// Keys should be obtained from ClusterLockKey, VolumeLockKey() or BrickLockKey().
 func CreateLockSteps(key string) (*Step, *Step, error) {
-	lockFunc, unlockFunc, err := createLockStepFunc(key)
+	return CreateLockStepsWithMode(key, LockExclusive)
+}
+
+// CreateSharedLockSteps returns a lock and an unlock Step which lock/unlock the given key
+// in shared mode, for operations which do not modify the locked resource
+func CreateSharedLockSteps(key string) (*Step, *Step, error) {
+	return CreateLockStepsWithMode(key, LockShared)
+}
+
+// CreateLockStepsWithMode returns a lock and an unlock Step which lock/unlock the given key
+// in the given mode
+func CreateLockStepsWithMode(key string, mode LockMode) (*Step, *Step, error) {
+	lockFunc, unlockFunc, err := createLockStepFunc(key, mode)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	lockStep := &Step{lockFunc, unlockFunc, []uuid.UUID{gdctx.MyUUID}}
-	unlockStep := &Step{unlockFunc, "", []uuid.UUID{gdctx.MyUUID}}
+	lockStep := &Step{
+		DoFunc:   lockFunc,
+		UndoFunc: unlockFunc,
+		Nodes:    []uuid.UUID{gdctx.MyUUID},
+		Lock:     &StepLock{Key: key, Mode: mode},
+	}
+	unlockStep := &Step{
+		DoFunc: unlockFunc,
+		Nodes:  []uuid.UUID{gdctx.MyUUID},
+		Lock:   &StepLock{Key: key, Mode: mode, Unlock: true},
+	}
 
 	return lockStep, unlockStep, nil
 }
+
+// lockStepKey returns the key locked by the given Step, if it is a lock Step
+// created by CreateLockSteps
+func lockStepKey(s *Step) (string, bool) {
+	if s.Lock == nil || s.Lock.Unlock {
+		return "", false
+	}
+	return s.Lock.Key, true
+}
+
+// isLockStep returns true if the given Step is a lock or unlock Step created by
+// CreateLockSteps
+func isLockStep(s *Step) bool {
+	return s.Lock != nil
+}
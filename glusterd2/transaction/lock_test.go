@@ -0,0 +1,124 @@
+package transaction
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLockKeys validates the lock hierarchy
+func TestLockKeys(t *testing.T) {
+	assert.Equal(t, "cluster/volumes/vol1", VolumeLockKey("vol1"))
+
+	brick := BrickLockKey("vol1", "host1:/bricks/b1")
+	assert.Equal(t, "cluster/volumes/vol1/bricks/host1:%2Fbricks%2Fb1", brick)
+	assert.Equal(t, []string{"cluster/volumes/vol1/bricks", "cluster/volumes/vol1", "cluster/volumes", "cluster"}, ancestorLockKeys(brick))
+	assert.Empty(t, ancestorLockKeys(ClusterLockKey))
+}
+
+// TestLockConflicts validates which lock modes conflict
+func TestLockConflicts(t *testing.T) {
+	key := VolumeLockKey("vol1")
+	shared := newRWLock(key, LockShared, "a")
+	exclusive := newRWLock(key, LockExclusive, "b")
+
+	assert.False(t, shared.conflicts(newRWLock(key, LockShared, "c").holderKey()))
+	assert.True(t, shared.conflicts(exclusive.holderKey()))
+	assert.True(t, exclusive.conflicts(shared.holderKey()))
+	assert.True(t, exclusive.conflicts(newRWLock(ClusterLockKey, LockExclusive, "c").holderKey()))
+
+	// Locks of the same holder never conflict
+	assert.False(t, exclusive.conflicts(newRWLock(BrickLockKey("vol1", "host1:/b1"), LockExclusive, "b").holderKey()))
+	assert.False(t, shared.conflicts(newRWLock(ClusterLockKey, LockExclusive, "a").holderKey()))
+}
+
+// TestLockStepKey validates identifying lock steps
+func TestLockStepKey(t *testing.T) {
+	key := VolumeLockKey("vol1")
+	for _, mode := range []LockMode{LockExclusive, LockShared} {
+		lock, unlock, err := CreateLockStepsWithMode(key, mode)
+		assert.Nil(t, err)
+
+		k, ok := lockStepKey(lock)
+		assert.True(t, ok)
+		assert.Equal(t, key, k)
+		assert.True(t, isLockStep(lock))
+
+		_, ok = lockStepKey(unlock)
+		assert.False(t, ok)
+		assert.True(t, isLockStep(unlock))
+	}
+	assert.False(t, isLockStep(&Step{DoFunc: "vol-create.Commit"}))
+	// Steps are not identified by the names of their StepFuncs
+	assert.False(t, isLockStep(&Step{DoFunc: key + lockFuncSuffix}))
+}
+
+// initTestStore starts an embedded store for the tests which need one
+func initTestStore(t *testing.T) func() {
+	if testing.Short() {
+		t.Skip("skipping embedded store test in short mode")
+	}
+
+	gdctx.MyUUID = uuid.NewRandom()
+	dir, err := ioutil.TempDir("", "gd2transaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	destroy, err := store.InitTestStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return func() {
+		destroy()
+		os.RemoveAll(dir)
+	}
+}
+
+// TestHierarchicalLocks validates locking keys above and below each other in
+// the store
+func TestHierarchicalLocks(t *testing.T) {
+	defer initTestStore(t)()
+
+	ctx := context.Background()
+	volume := VolumeLockKey("vol1")
+	brick := BrickLockKey("vol1", "host1:/bricks/b1")
+	timeout := 500 * time.Millisecond
+
+	lockWithTimeout := func(l *rwLock) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return l.Lock(ctx)
+	}
+
+	// A holder can lock a volume and its bricks
+	vol := newRWLock(volume, LockExclusive, "a")
+	assert.Nil(t, lockWithTimeout(vol))
+	assert.Nil(t, lockWithTimeout(newRWLock(brick, LockExclusive, "a")))
+
+	// Other holders can lock neither the volume, nor its bricks, nor the
+	// cluster
+	assert.Equal(t, context.DeadlineExceeded, lockWithTimeout(newRWLock(brick, LockShared, "b")))
+	assert.Equal(t, context.DeadlineExceeded, lockWithTimeout(newRWLock(volume, LockShared, "b")))
+	assert.Equal(t, context.DeadlineExceeded, lockWithTimeout(newRWLock(ClusterLockKey, LockExclusive, "b")))
+
+	// Until the holder unlocks both
+	assert.Nil(t, vol.Unlock(ctx))
+	assert.Equal(t, context.DeadlineExceeded, lockWithTimeout(newRWLock(volume, LockShared, "b")))
+	assert.Nil(t, newRWLock(brick, LockExclusive, "a").Unlock(ctx))
+	assert.Nil(t, lockWithTimeout(newRWLock(volume, LockShared, "b")))
+
+	// Shared locks of different holders don't conflict, on the same key or
+	// on keys below it
+	assert.Nil(t, lockWithTimeout(newRWLock(volume, LockShared, "c")))
+	assert.Nil(t, lockWithTimeout(newRWLock(brick, LockShared, "d")))
+	assert.Equal(t, context.DeadlineExceeded, lockWithTimeout(newRWLock(brick, LockExclusive, "e")))
+}
@@ -0,0 +1,50 @@
+package transaction
+
+// This file implements checking that the nodes of a transaction support the
+// StepFuncs they are asked to run, for clusters with nodes running different
+// versions of GlusterD, like during a rolling upgrade. StepFuncs introduced
+// in an op-version are only run once the cluster op-version has been raised
+// to it.
+
+import (
+	"fmt"
+
+	"github.com/gluster/glusterd2/glusterd2/cluster"
+	"github.com/gluster/glusterd2/version"
+)
+
+// stepOpVersion returns the op-version nodes need to support to run the step
+func stepOpVersion(s *Step) int {
+	opVersion := version.MinOpVersion
+	for _, name := range []string{s.DoFunc, s.UndoFunc} {
+		if v, ok := GetStepFuncOpVersion(name); ok && v > opVersion {
+			opVersion = v
+		}
+	}
+	return opVersion
+}
+
+// checkOpVersions checks that the cluster op-version is at least the
+// op-version of the StepFuncs of every step, so that the transaction doesn't
+// fail midway with ErrStepFuncNotFound. All the peers support the cluster
+// op-version, so the nodes of the steps don't need to be checked one by one.
+func checkOpVersions(steps []*Step) error {
+	var clusterOpVersion int
+	for _, s := range steps {
+		need := stepOpVersion(s)
+		if need <= version.MinOpVersion {
+			continue
+		}
+
+		if clusterOpVersion == 0 {
+			var err error
+			if clusterOpVersion, err = cluster.OpVersion(); err != nil {
+				return err
+			}
+		}
+		if clusterOpVersion < need {
+			return fmt.Errorf("step %s requires cluster op-version %d, but the cluster op-version is %d", s.DoFunc, need, clusterOpVersion)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package transaction
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/version"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStepOpVersion validates the op-version required to run steps
+func TestStepOpVersion(t *testing.T) {
+	sf := func(TxnCtx) error { return nil }
+	RegisterStepFunc(sf, "test-opversion.Old")
+	RegisterStepFuncWithOpVersion(sf, "test-opversion.New", version.MinOpVersion+1)
+
+	v, ok := GetStepFuncOpVersion("test-opversion.New")
+	assert.True(t, ok)
+	assert.Equal(t, version.MinOpVersion+1, v)
+	_, ok = GetStepFuncOpVersion("test-opversion.Missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, version.MinOpVersion, stepOpVersion(&Step{DoFunc: "test-opversion.Old"}))
+	assert.Equal(t, version.MinOpVersion+1, stepOpVersion(&Step{DoFunc: "test-opversion.New"}))
+	assert.Equal(t, version.MinOpVersion+1, stepOpVersion(&Step{DoFunc: "test-opversion.Old", UndoFunc: "test-opversion.New"}))
+
+	// Steps supported by all op-versions don't need the nodes to be looked up
+	steps := []*Step{{DoFunc: "test-opversion.Old", Nodes: []uuid.UUID{uuid.NewRandom()}}}
+	assert.Nil(t, checkOpVersions(steps))
+}
+
+// TestCheckOpVersions validates that steps are only run once the cluster
+// op-version supports them
+func TestCheckOpVersions(t *testing.T) {
+	defer initTestStore(t)()
+
+	sf := func(TxnCtx) error { return nil }
+	RegisterStepFuncWithOpVersion(sf, "test-opversion.Next", version.MinOpVersion+1)
+	steps := []*Step{{DoFunc: "test-opversion.Next", Nodes: []uuid.UUID{uuid.NewRandom()}}}
+
+	// The cluster op-version defaults to the lowest op-version
+	assert.NotNil(t, checkOpVersions(steps))
+
+	// Raised as if all the peers had been upgraded
+	_, err := store.Store.Put(context.Background(), store.GlusterPrefix+"cluster/opversion", strconv.Itoa(version.MinOpVersion+1))
+	assert.Nil(t, err)
+	assert.Nil(t, checkOpVersions(steps))
+}
@@ -0,0 +1,115 @@
+package transaction
+
+// This file implements running independent transaction steps concurrently.
+//
+// The steps of a transaction are run in groups. A group is either a single
+// step which is not Parallel, or a run of consecutive Parallel steps. Groups
+// are run one after the other, and the steps of a group are run concurrently,
+// each one once the steps it depends on have completed. If a step of a group
+// fails, the steps of the group which have not been started yet are not run,
+// and the transaction is rolled back once the running steps complete.
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errStepNotRun is the error in the results of the Parallel steps which were
+// not run because another step of their group failed
+var errStepNotRun = errors.New("step was not run because another step failed")
+
+// validateSteps checks that the steps only depend on the steps before them
+func validateSteps(steps []*Step) error {
+	for i, s := range steps {
+		for _, d := range s.DependsOn {
+			if d < 0 || d >= i {
+				return fmt.Errorf("step %d (%s) depends on step %d, which is not before it", i, s.DoFunc, d)
+			}
+		}
+	}
+	return nil
+}
+
+// stepGroupEnd returns the index after the last step of the group starting at
+// the given step
+func (t *Txn) stepGroupEnd(start int) int {
+	end := start + 1
+	if !t.Steps[start].Parallel {
+		return end
+	}
+	for end < len(t.Steps) && t.Steps[end].Parallel {
+		end++
+	}
+	return end
+}
+
+// runStepGroup runs the steps of the group from start to end, and returns once
+// all of them have completed. Steps which already succeeded, when rolling
+// forward a recovered transaction, are not run again.
+func (t *Txn) runStepGroup(start, end int) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed bool
+		done   = make([]chan struct{}, end-start)
+	)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	for i := start; i < end; i++ {
+		if r := t.Results[i]; r != nil && r.Err() == nil {
+			close(done[i-start])
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i-start])
+
+			s := t.Steps[i]
+			for _, d := range s.DependsOn {
+				if d >= start {
+					<-done[d-start]
+				}
+			}
+
+			mu.Lock()
+			stop := failed
+			mu.Unlock()
+
+			var r *StepResult
+			if stop {
+				r = abortedStepResult(s, errStepNotRun)
+			} else if e := t.Ctx.Context().Err(); e != nil {
+				// The transaction has been cancelled, don't run any more steps
+				r = abortedStepResult(s, e)
+			} else {
+				r = s.do(t.Ctx)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			t.Results[i] = r
+			if r.Err() != nil {
+				failed = true
+			}
+			t.updateJob()
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// stepGroupFailure returns the result of the first step of the group from
+// start to end which failed, or nil if all of them succeeded
+func (t *Txn) stepGroupFailure(start, end int) *StepResult {
+	for i := start; i < end; i++ {
+		if r := t.Results[i]; r.Err() != nil && Cause(r.Err()) != errStepNotRun {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStepGroups validates how steps are grouped to be run concurrently
+func TestStepGroups(t *testing.T) {
+	txn := &Txn{
+		Steps: []*Step{
+			{DoFunc: "test-group.Lock"},
+			{DoFunc: "test-group.A", Parallel: true},
+			{DoFunc: "test-group.B", Parallel: true},
+			{DoFunc: "test-group.C", Parallel: true, DependsOn: []int{1}},
+			{DoFunc: "test-group.Unlock"},
+		},
+	}
+
+	assert.Equal(t, 1, txn.stepGroupEnd(0))
+	assert.Equal(t, 4, txn.stepGroupEnd(1))
+	assert.Equal(t, 4, txn.stepGroupEnd(2))
+	assert.Equal(t, 5, txn.stepGroupEnd(4))
+
+	assert.Nil(t, validateSteps(txn.Steps))
+	assert.NotNil(t, validateSteps([]*Step{{DoFunc: "test-group.A", DependsOn: []int{0}}}))
+}
+
+// TestRunStepGroup validates that dependencies are run first, and that steps
+// are not started once a step of the group fails
+func TestRunStepGroup(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+	nodes := []uuid.UUID{gdctx.MyUUID}
+
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	record := func(name string, err error) StepFunc {
+		return func(TxnCtx) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name)
+			return err
+		}
+	}
+	RegisterStepFunc(record("first", nil), "test-parallel.First")
+	RegisterStepFunc(record("second", nil), "test-parallel.Second")
+	RegisterStepFunc(record("fail", errors.New("step failed")), "test-parallel.Fail")
+
+	txn := &Txn{
+		Ctx: NewMockCtx(),
+		Steps: []*Step{
+			{DoFunc: "test-parallel.First", Nodes: nodes, Parallel: true},
+			{DoFunc: "test-parallel.Second", Nodes: nodes, Parallel: true, DependsOn: []int{0}},
+		},
+	}
+	txn.Results = make([]*StepResult, len(txn.Steps))
+	txn.runStepGroup(0, 2)
+	assert.Equal(t, []string{"first", "second"}, ran)
+	assert.Nil(t, txn.stepGroupFailure(0, 2))
+
+	ran = nil
+	txn.Steps = []*Step{
+		{DoFunc: "test-parallel.Fail", Nodes: nodes, Parallel: true},
+		{DoFunc: "test-parallel.Second", Nodes: nodes, Parallel: true, DependsOn: []int{0}},
+	}
+	txn.Results = make([]*StepResult, len(txn.Steps))
+	txn.runStepGroup(0, 2)
+	assert.Equal(t, []string{"fail"}, ran)
+	assert.Equal(t, "test-parallel.Fail", txn.stepGroupFailure(0, 2).StepFunc)
+	assert.Equal(t, errStepNotRun, Cause(txn.Results[1].Err()))
+	assert.Empty(t, txn.undoNodes(1))
+}
@@ -5,32 +5,49 @@ package transaction
 import (
 	"sync"
 
+	"github.com/gluster/glusterd2/version"
+
 	log "github.com/sirupsen/logrus"
 )
 
+// registeredStepFunc is a StepFunc and the op-version which introduced it
+type registeredStepFunc struct {
+	sf        StepFunc
+	opVersion int
+}
+
 var sfRegistry = struct {
 	sync.RWMutex
-	sfMap map[string]StepFunc
+	sfMap map[string]registeredStepFunc
 }{}
 
-func registerStepFunc(s StepFunc, name string) {
+func registerStepFunc(s StepFunc, name string, opVersion int) {
 	if sfRegistry.sfMap == nil {
-		sfRegistry.sfMap = make(map[string]StepFunc)
+		sfRegistry.sfMap = make(map[string]registeredStepFunc)
 	}
 
 	if _, ok := sfRegistry.sfMap[name]; ok {
 		log.WithField("stepname", name).Warning("step with provided name exists in registry and will be overwritten")
 	}
 
-	sfRegistry.sfMap[name] = s
+	sfRegistry.sfMap[name] = registeredStepFunc{s, opVersion}
 }
 
-//RegisterStepFunc registers the given StepFunc in the registry
+//RegisterStepFunc registers the given StepFunc in the registry, as supported
+//by all op-versions
 func RegisterStepFunc(s StepFunc, name string) {
+	RegisterStepFuncWithOpVersion(s, name, version.MinOpVersion)
+}
+
+//RegisterStepFuncWithOpVersion registers the given StepFunc in the registry,
+//as introduced in the given op-version. StepFuncs must be registered under a
+//new name when their behaviour changes, so that transactions only run them
+//once the cluster op-version supports them.
+func RegisterStepFuncWithOpVersion(s StepFunc, name string, opVersion int) {
 	sfRegistry.Lock()
 	defer sfRegistry.Unlock()
 
-	registerStepFunc(s, name)
+	registerStepFunc(s, name, opVersion)
 }
 
 //GetStepFunc returns named step if found.
@@ -38,6 +55,16 @@ func GetStepFunc(name string) (StepFunc, bool) {
 	sfRegistry.RLock()
 	defer sfRegistry.RUnlock()
 
-	s, ok := sfRegistry.sfMap[name]
-	return s, ok
+	r, ok := sfRegistry.sfMap[name]
+	return r.sf, ok
+}
+
+//GetStepFuncOpVersion returns the op-version which introduced the named step,
+//if found.
+func GetStepFuncOpVersion(name string) (int, bool) {
+	sfRegistry.RLock()
+	defer sfRegistry.RUnlock()
+
+	r, ok := sfRegistry.sfMap[name]
+	return r.opVersion, ok
 }
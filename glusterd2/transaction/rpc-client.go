@@ -5,18 +5,14 @@ import (
 	"errors"
 
 	"github.com/gluster/glusterd2/glusterd2/peer"
-	"github.com/gluster/glusterd2/pkg/utils"
+	"github.com/gluster/glusterd2/glusterd2/servers/peerrpc"
 
 	"github.com/pborman/uuid"
 	log "github.com/sirupsen/logrus"
-	netctx "golang.org/x/net/context"
-	"google.golang.org/grpc"
 )
 
 // RunStepOn will run the step on the specified node
 func RunStepOn(step string, node uuid.UUID, c TxnCtx) (TxnCtx, error) {
-	// TODO: I'm creating connections on demand. This should be changed so that
-	// we have long term connections.
 	p, err := peer.GetPeerF(node.String())
 	if err != nil {
 		c.Logger().WithFields(log.Fields{
@@ -28,45 +24,32 @@ func RunStepOn(step string, node uuid.UUID, c TxnCtx) (TxnCtx, error) {
 
 	logger := c.Logger().WithField("remotepeer", p.ID.String()+"("+p.Name+")")
 
-	var conn *grpc.ClientConn
-
-	remote, err := utils.FormRemotePeerAddress(p.Addresses[0])
+	// Connections to peers are pooled and reused across transactions
+	conn, release, err := peerrpc.GetConn(p.ID.String(), p.Addresses)
 	if err != nil {
-		return nil, err
-	}
-
-	conn, err = grpc.Dial(remote, grpc.WithInsecure())
-	if err == nil && conn != nil {
-		logger.WithFields(log.Fields{
-			"remote": remote,
-		}).Debug("connected to remote")
-	}
-
-	if conn == nil {
 		logger.WithFields(log.Fields{
 			"error":  err,
 			"remote": p.Addresses,
-		}).Error("failed to grpc.Dial remote")
+		}).Error("failed to connect to remote")
 		return nil, err
 	}
-	defer conn.Close()
+	defer release()
 
 	client := NewTxnSvcClient(conn)
 
-	req := &TxnStepReq{
-		StepFunc: step,
-	}
-	data, err := json.Marshal(c)
+	req, err := newTxnStepReq(step, c)
 	if err != nil {
 		logger.WithError(err).Error("failed to JSON marshal transaction context")
 		return nil, err
 	}
-	req.Context = data
 
 	var rsp *TxnStepResp
 
-	rsp, err = client.RunStep(netctx.TODO(), req)
+	// The deadline and cancellation of the context are propagated to the
+	// remote node by gRPC
+	rsp, err = client.RunStep(c.Context(), req)
 	if err != nil {
+		peerrpc.ReportError(p.ID.String(), conn, err)
 		logger.WithFields(log.Fields{
 			"error": err,
 			"rpc":   "TxnSvc.RunStep",
@@ -79,11 +62,48 @@ func RunStepOn(step string, node uuid.UUID, c TxnCtx) (TxnCtx, error) {
 		return nil, errors.New(rsp.Error)
 	}
 
-	rspCtx := new(Tctx)
-	err = json.Unmarshal(rsp.Resp, rspCtx)
+	rspCtx, err := applyTxnStepResp(c, rsp)
 	if err != nil {
 		logger.WithError(err).Error("failed to JSON unmarhsal transaction context")
 	}
 
 	return rspCtx, err
 }
+
+// newTxnStepReq returns the request to run the given step with the given
+// TxnCtx. The data of an InlineCtx is sent along with the request.
+func newTxnStepReq(step string, c TxnCtx) (*TxnStepReq, error) {
+	req := &TxnStepReq{
+		StepFunc: step,
+	}
+
+	var err error
+	if ic, ok := c.(*InlineCtx); ok {
+		req.Inline = true
+		req.Data = ic.values()
+		req.Context, err = json.Marshal(ic.Tctx)
+	} else {
+		req.Context, err = json.Marshal(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// applyTxnStepResp returns the TxnCtx in the response to a step. If c is an
+// InlineCtx, the changes made to it by the step are applied to c, which is
+// returned.
+func applyTxnStepResp(c TxnCtx, rsp *TxnStepResp) (TxnCtx, error) {
+	if ic, ok := c.(*InlineCtx); ok {
+		ic.apply(rsp.Data, rsp.Deleted)
+		return ic, nil
+	}
+
+	rspCtx := new(Tctx)
+	if err := json.Unmarshal(rsp.Resp, rspCtx); err != nil {
+		return nil, err
+	}
+	return rspCtx, nil
+}
@@ -40,8 +40,23 @@ func (p *txnSvc) RunStep(rpcCtx context.Context, req *TxnStepReq) (*TxnStepResp,
 
 	resp := new(TxnStepResp)
 
-	// Execute the step function, build and return result
-	err = f(&ctx)
+	// Execute the step function, build and return result. The step function
+	// is given the RPC context, which is cancelled if the originator of the
+	// transaction aborts the step.
+	if req.Inline {
+		// The data of an InlineCtx is sent back to the originator of the
+		// transaction, and is not stored
+		ictx := &InlineCtx{Tctx: ctx.WithContext(rpcCtx), data: newInlineData(req.Data)}
+		if err = f(ictx); err != nil {
+			logger.WithError(err).Debug("step function failed")
+			resp.Error = err.Error()
+		} else {
+			resp.Data, resp.Deleted = ictx.changes()
+		}
+		return resp, nil
+	}
+
+	err = f(ctx.WithContext(rpcCtx))
 	if err != nil {
 		logger.WithError(err).Debug("step function failed")
 		resp.Error = err.Error()
@@ -0,0 +1,248 @@
+package transaction
+
+// This file implements hierarchical reader/writer locks in the store.
+//
+// Lock keys form a hierarchy, with the cluster at the top, followed by volumes
+// and bricks. A lock on a key also covers all the keys below it, so two locks
+// conflict if their keys are the same or one is below the other, and at least
+// one of them is exclusive. For example, an exclusive lock on the cluster
+// conflicts with all other locks, and shared locks on a volume do not conflict
+// with each other, but do conflict with an exclusive lock on a brick of the
+// volume.
+//
+// Each holder of a lock puts a key, attached to the store session lease, at
+// `<lockPrefix><lock key>/@<mode>/<holder>`. Like the etcd concurrency.Mutex,
+// locks are granted in the order they were requested, by waiting for all
+// conflicting holder keys with a lower create revision to be deleted.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/pborman/uuid"
+)
+
+// LockMode is the mode in which a lock is held
+type LockMode int
+
+const (
+	// LockExclusive locks conflict with all other locks on the same key
+	LockExclusive LockMode = iota
+	// LockShared locks only conflict with exclusive locks on the same key
+	LockShared
+)
+
+func (m LockMode) String() string {
+	if m == LockShared {
+		return "shared"
+	}
+	return "exclusive"
+}
+
+const (
+	// ClusterLockKey is the lock key for operations affecting the whole
+	// cluster. It is the top of the lock hierarchy.
+	ClusterLockKey = "cluster"
+
+	holderSep = "/@"
+)
+
+// VolumeLockKey returns the lock key for the given volume
+func VolumeLockKey(volname string) string {
+	return ClusterLockKey + "/volumes/" + volname
+}
+
+// BrickLockKey returns the lock key for the given brick, of the form
+// <host>:<path>, of the given volume
+func BrickLockKey(volname, brick string) string {
+	return VolumeLockKey(volname) + "/bricks/" + url.PathEscape(brick)
+}
+
+// ancestorLockKeys returns the keys above the given key in the lock hierarchy
+func ancestorLockKeys(key string) []string {
+	var keys []string
+	for i := strings.LastIndex(key, "/"); i > 0; i = strings.LastIndex(key, "/") {
+		key = key[:i]
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// rwLock is a lock on a key of the lock hierarchy held by a single holder
+type rwLock struct {
+	key    string
+	mode   LockMode
+	holder string
+}
+
+func newRWLock(key string, mode LockMode, holder string) *rwLock {
+	return &rwLock{key, mode, holder}
+}
+
+// holderKey is the store key put by the holder of the lock
+func (l *rwLock) holderKey() string {
+	return fmt.Sprintf("%s%s%s%s/%s", lockPrefix, l.key, holderSep, l.mode, l.holder)
+}
+
+// conflicts returns true if the lock conflicts with the given holder key.
+// Locks never conflict with other locks of the same holder, so that a
+// transaction can lock both a key and the keys below it.
+func (l *rwLock) conflicts(holderKey string) bool {
+	i := strings.LastIndex(holderKey, holderSep)
+	if i < 0 {
+		return false
+	}
+	modeHolder := holderKey[i+len(holderSep):]
+	if path.Base(modeHolder) == l.holder {
+		return false
+	}
+	if l.mode == LockExclusive {
+		return true
+	}
+	return strings.HasPrefix(modeHolder, LockExclusive.String()+"/")
+}
+
+// Lock obtains the lock, blocking until it is obtained or ctx is done
+func (l *rwLock) Lock(ctx context.Context) error {
+	return l.lock(ctx, false)
+}
+
+// Relock obtains the lock for a holder which may still hold it with a lost
+// session, like the previous instance of this node. The existing holder key is
+// attached to the current session, keeping its place in the lock order.
+func (l *rwLock) Relock(ctx context.Context) error {
+	return l.lock(ctx, true)
+}
+
+func (l *rwLock) lock(ctx context.Context, takeover bool) error {
+	key := l.holderKey()
+	// The holder key is lost with the session lease, so stop waiting if the
+	// session ends
+	session := store.Store.Session()
+
+	var rev int64
+	if takeover {
+		resp, err := store.Store.Put(ctx, key, "", clientv3.WithLease(session.Lease()), clientv3.WithPrevKV())
+		if err != nil {
+			return err
+		}
+		rev = resp.Header.Revision
+		if resp.PrevKv != nil {
+			rev = resp.PrevKv.CreateRevision
+		}
+	} else {
+		cmp := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		put := clientv3.OpPut(key, "", clientv3.WithLease(session.Lease()))
+		get := clientv3.OpGet(key)
+		resp, err := store.Store.Txn(ctx).If(cmp).Then(put).Else(get).Commit()
+		if err != nil {
+			return err
+		}
+		rev = resp.Header.Revision
+		if !resp.Succeeded {
+			rev = resp.Responses[0].GetResponseRange().Kvs[0].CreateRevision
+		}
+	}
+
+	for {
+		blocker, watchRev, err := l.blocker(ctx, rev)
+		if err == nil && blocker == "" {
+			return nil
+		}
+		if err == nil {
+			err = waitDelete(ctx, session.Done(), blocker, watchRev)
+		}
+		if err != nil {
+			l.Unlock(context.Background())
+			return err
+		}
+	}
+}
+
+// blocker returns a conflicting holder key that was created before the
+// given revision, and the revision at which it was seen. An empty key is
+// returned if there are no such holders.
+func (l *rwLock) blocker(ctx context.Context, rev int64) (string, int64, error) {
+	// Holders of the same key and of the keys below it
+	prefixes := []string{lockPrefix + l.key + "/"}
+	// Holders of the keys above it
+	for _, a := range ancestorLockKeys(l.key) {
+		prefixes = append(prefixes, lockPrefix+a+holderSep)
+	}
+
+	for _, p := range prefixes {
+		resp, err := store.Store.Get(ctx, p, clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithMaxCreateRev(rev-1))
+		if err != nil {
+			return "", 0, err
+		}
+		for _, kv := range resp.Kvs {
+			if l.conflicts(string(kv.Key)) {
+				return string(kv.Key), resp.Header.Revision, nil
+			}
+		}
+	}
+
+	return "", 0, nil
+}
+
+// waitDelete waits for the given key to be deleted after the given revision.
+// store.ErrSessionLost is returned if sessionDone is closed while waiting.
+func waitDelete(ctx context.Context, sessionDone <-chan struct{}, key string, rev int64) error {
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wch := store.Store.Watch(cctx, key, clientv3.WithRev(rev))
+	for {
+		select {
+		case wr, ok := <-wch:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return errors.New("lost watcher waiting for lock to be released")
+			}
+			for _, ev := range wr.Events {
+				if ev.Type == mvccpb.DELETE {
+					return nil
+				}
+			}
+		case <-sessionDone:
+			return store.ErrSessionLost
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *rwLock) Unlock(ctx context.Context) error {
+	_, err := store.Store.Delete(ctx, l.holderKey())
+	return err
+}
+
+// Lock obtains a lock on the given key, for operations that are not run as
+// transactions. The returned function releases the lock. ErrLockTimeout is
+// returned if the lock could not be obtained in time.
+func Lock(ctx context.Context, key string, mode LockMode) (func(), error) {
+	l := newRWLock(key, mode, uuid.NewRandom().String())
+
+	ctx, cancel := context.WithTimeout(ctx, lockObtainTimeout)
+	defer cancel()
+
+	if err := l.Lock(ctx); err != nil {
+		if err == context.DeadlineExceeded {
+			err = ErrLockTimeout
+		}
+		return nil, err
+	}
+
+	return func() {
+		l.Unlock(context.Background())
+	}, nil
+}
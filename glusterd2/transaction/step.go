@@ -1,7 +1,12 @@
 package transaction
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
 
@@ -16,57 +21,221 @@ type StepFunc func(TxnCtx) error
 // DoFunc and UndoFunc are names of StepFuncs registered in the registry
 // DoFunc performs does the action
 // UndoFunc undoes anything done by DoFunc
+// Timeout is the time after which the context.Context of the step is
+// cancelled, on all nodes. Remote nodes which have not responded by then are
+// treated as failed. Steps without a Timeout use defaultStepTimeout, so that a
+// hung peer can't stall a transaction forever.
+// Validate marks steps which only check that the transaction can be done,
+// without changing anything. Only these steps, and the lock steps, are run by
+// transactions which are dry runs.
+// Parallel marks steps which may run concurrently with the other Parallel
+// steps next to them. Steps which are not Parallel are only run after all the
+// steps before them have completed.
+// DependsOn lists the indexes, in Txn.Steps, of the earlier Parallel steps
+// which must complete before a Parallel step is run.
+// Lock is set on the lock and unlock steps created by CreateLockSteps.
 type Step struct {
-	DoFunc   string
-	UndoFunc string
-	Nodes    []uuid.UUID
+	DoFunc    string
+	UndoFunc  string
+	Nodes     []uuid.UUID
+	Timeout   time.Duration
+	Validate  bool
+	Parallel  bool
+	DependsOn []int
+	Lock      *StepLock
+}
+
+// StepLock describes the lock taken or released by a lock or unlock step
+type StepLock struct {
+	Key    string
+	Mode   LockMode
+	Unlock bool
 }
 
 var (
 	// ErrStepFuncNotFound is returned if the stepfunc isn't found.
 	ErrStepFuncNotFound = errors.New("StepFunc was not found")
+
+	// defaultStepTimeout is the Timeout of the steps which don't set one
+	defaultStepTimeout = 5 * time.Minute
 )
 
+// NodeResult is the result of running a StepFunc on a single node
+type NodeResult struct {
+	NodeID   uuid.UUID     `json:"node-id"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+
+	err error
+}
+
+// Err returns the error returned by the StepFunc on the node, if any
+func (r *NodeResult) Err() error {
+	return r.err
+}
+
+// StepResult is the aggregated result of running a StepFunc on all the nodes
+// of a Step. The Nodes are in the same order as the nodes of the Step.
+type StepResult struct {
+	StepFunc string       `json:"step"`
+	Nodes    []NodeResult `json:"nodes"`
+}
+
+// Failed returns the results of the nodes on which the StepFunc failed
+func (r *StepResult) Failed() []NodeResult {
+	var failed []NodeResult
+	for _, n := range r.Nodes {
+		if !n.Success {
+			failed = append(failed, n)
+		}
+	}
+	return failed
+}
+
+// Err returns a *StepError if the StepFunc failed on any of the nodes, or nil
+func (r *StepResult) Err() error {
+	if len(r.Failed()) == 0 {
+		return nil
+	}
+	return &StepError{StepResult: r}
+}
+
+func (r *StepResult) String() string {
+	failed := r.Failed()
+
+	errs := make([]string, 0, len(failed))
+	for _, n := range failed {
+		errs = append(errs, fmt.Sprintf("%s: %s", n.NodeID.String(), n.Error))
+	}
+
+	return fmt.Sprintf("step %s failed on %d node(s): %s", r.StepFunc, len(failed), strings.Join(errs, "; "))
+}
+
+// StepError is returned when a StepFunc fails on one or more nodes
+type StepError struct {
+	*StepResult
+	// UndoFailures holds the results of the UndoFuncs that failed while
+	// rolling back the transaction after the failure
+	UndoFailures []*StepResult
+}
+
+func (e *StepError) Error() string {
+	msg := e.StepResult.String()
+	for _, u := range e.UndoFailures {
+		msg += "; rollback " + u.String()
+	}
+	return msg
+}
+
+// Cause returns the error of the first node on which the StepFunc failed. It
+// can be compared against errors like ErrLockTimeout.
+func (e *StepError) Cause() error {
+	for _, n := range e.Nodes {
+		if !n.Success {
+			return n.err
+		}
+	}
+	return nil
+}
+
+// Cause returns the underlying error of a StepError, or err itself if it is
+// not a StepError.
+func Cause(err error) error {
+	if serr, ok := err.(*StepError); ok {
+		return serr.Cause()
+	}
+	return err
+}
+
 // do runs the DoFunc on the nodes
-func (s *Step) do(c TxnCtx) error {
+func (s *Step) do(c TxnCtx) *StepResult {
+	c, cancel := s.withTimeout(c)
+	defer cancel()
+
 	return runStepFuncOnNodes(s.DoFunc, c, s.Nodes)
 }
 
-// undo runs the UndoFunc on the nodes
-func (s *Step) undo(c TxnCtx) error {
-	if s.UndoFunc != "" {
-		return runStepFuncOnNodes(s.UndoFunc, c, s.Nodes)
+// undo runs the UndoFunc on the given nodes, which should be the nodes on
+// which the DoFunc succeeded
+func (s *Step) undo(c TxnCtx, nodes []uuid.UUID) *StepResult {
+	if s.UndoFunc == "" || len(nodes) == 0 {
+		return nil
 	}
-	return nil
+
+	c, cancel := s.withTimeout(c)
+	defer cancel()
+
+	return runStepFuncOnNodes(s.UndoFunc, c, nodes)
+}
+
+// withTimeout returns a TxnCtx whose context.Context is cancelled once the
+// Timeout of the step, or defaultStepTimeout, expires
+func (s *Step) withTimeout(c TxnCtx) (TxnCtx, context.CancelFunc) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultStepTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
+	return withContext(c, ctx), cancel
 }
 
-func runStepFuncOnNodes(name string, c TxnCtx, nodes []uuid.UUID) error {
-	var (
-		i    int
-		node uuid.UUID
-	)
-	done := make(chan error)
-	defer close(done)
+// abortedStepResult returns the result for a step that was not run on any
+// of its nodes because the transaction was aborted
+func abortedStepResult(s *Step, err error) *StepResult {
+	result := &StepResult{
+		StepFunc: s.DoFunc,
+		Nodes:    make([]NodeResult, len(s.Nodes)),
+	}
+	for i, node := range s.Nodes {
+		result.Nodes[i] = NodeResult{
+			NodeID: node,
+			Error:  err.Error(),
+			err:    err,
+		}
+	}
+	return result
+}
 
-	for i, node = range nodes {
-		go runStepFuncOnNode(name, c, node, done)
+func runStepFuncOnNodes(name string, c TxnCtx, nodes []uuid.UUID) *StepResult {
+	result := &StepResult{
+		StepFunc: name,
+		Nodes:    make([]NodeResult, len(nodes)),
 	}
 
-	// TODO: Need to properly aggregate results
-	var err error
-	for i >= 0 {
-		err = <-done
-		i--
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node uuid.UUID) {
+			defer wg.Done()
+			result.Nodes[i] = runStepFuncOnNode(name, c, node)
+		}(i, node)
 	}
-	return err
+	wg.Wait()
+
+	return result
 }
 
-func runStepFuncOnNode(name string, c TxnCtx, node uuid.UUID, done chan<- error) {
+func runStepFuncOnNode(name string, c TxnCtx, node uuid.UUID) NodeResult {
+	var err error
+
+	start := time.Now()
 	if uuid.Equal(node, gdctx.MyUUID) {
-		done <- runStepFuncLocal(name, c)
+		err = runStepFuncLocal(name, c)
 	} else {
-		done <- runStepFuncRemote(name, c, node)
+		err = runStepFuncRemote(name, c, node)
+	}
+
+	r := NodeResult{
+		NodeID:   node,
+		Success:  err == nil,
+		Duration: time.Since(start),
+		err:      err,
+	}
+	if err != nil {
+		r.Error = err.Error()
 	}
+	return r
 }
 
 func runStepFuncLocal(name string, c TxnCtx) error {
@@ -77,12 +246,13 @@ func runStepFuncLocal(name string, c TxnCtx) error {
 		return ErrStepFuncNotFound
 	}
 	return stepFunc(c)
-	//TODO: Results need to be aggregated
 }
 
 func runStepFuncRemote(step string, c TxnCtx, node uuid.UUID) error {
-	rsp, err := RunStepOn(step, node, c)
-	//TODO: Results need to be aggregated
-	_ = rsp
+	// The TxnCtx returned by the remote node refers to the same store prefix
+	// as c, or, for an InlineCtx, is c with the changes made by the remote
+	// StepFunc applied. Any results set by the remote StepFunc are already
+	// visible through c and the returned context can be ignored.
+	_, err := RunStepOn(step, node, c)
 	return err
 }
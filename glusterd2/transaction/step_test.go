@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRunStepFuncOnNodes validates that results are aggregated per node
+func TestRunStepFuncOnNodes(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+
+	RegisterStepFunc(func(TxnCtx) error { return nil }, "test-step.Pass")
+	RegisterStepFunc(func(TxnCtx) error { return ErrLockTimeout }, "test-step.Fail")
+
+	r := runStepFuncOnNodes("test-step.Pass", NewMockCtx(), []uuid.UUID{gdctx.MyUUID})
+	assert.Len(t, r.Nodes, 1)
+	assert.True(t, r.Nodes[0].Success)
+	assert.Empty(t, r.Failed())
+	assert.Nil(t, r.Err())
+
+	r = runStepFuncOnNodes("test-step.Fail", NewMockCtx(), []uuid.UUID{gdctx.MyUUID})
+	assert.Len(t, r.Failed(), 1)
+	assert.Equal(t, ErrLockTimeout.Error(), r.Nodes[0].Error)
+
+	err := r.Err()
+	assert.IsType(t, &StepError{}, err)
+	assert.Equal(t, ErrLockTimeout, Cause(err))
+
+	r = runStepFuncOnNodes("test-step.NotRegistered", NewMockCtx(), []uuid.UUID{gdctx.MyUUID})
+	assert.Equal(t, ErrStepFuncNotFound, Cause(r.Err()))
+
+	r = runStepFuncOnNodes("test-step.Pass", NewMockCtx(), nil)
+	assert.Empty(t, r.Nodes)
+	assert.Nil(t, r.Err())
+}
+
+// TestCause validates Cause() for errors which are not StepErrors
+func TestCause(t *testing.T) {
+	err := errors.New("some error")
+	assert.Equal(t, err, Cause(err))
+	assert.Nil(t, Cause(nil))
+}
+
+// TestStepTimeout validates that the step timeout reaches the StepFunc
+func TestStepTimeout(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+
+	RegisterStepFunc(func(c TxnCtx) error {
+		<-c.Context().Done()
+		return c.Context().Err()
+	}, "test-step.Block")
+
+	s := &Step{
+		DoFunc:  "test-step.Block",
+		Nodes:   []uuid.UUID{gdctx.MyUUID},
+		Timeout: 10 * time.Millisecond,
+	}
+	r := s.do(NewCtx())
+	assert.Equal(t, context.DeadlineExceeded, Cause(r.Err()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Timeout = 0
+	r = s.do(NewCtx().WithContext(ctx))
+	assert.Equal(t, context.Canceled, Cause(r.Err()))
+
+	// Steps without a timeout use the default one
+	defer func(d time.Duration) {
+		defaultStepTimeout = d
+	}(defaultStepTimeout)
+	defaultStepTimeout = 10 * time.Millisecond
+	r = s.do(NewCtx())
+	assert.Equal(t, context.DeadlineExceeded, Cause(r.Err()))
+}
+
+// TestAbortedStepResult validates the result of steps which were not run
+func TestAbortedStepResult(t *testing.T) {
+	s := &Step{DoFunc: "test-step.Pass", Nodes: []uuid.UUID{uuid.NewRandom(), uuid.NewRandom()}}
+
+	r := abortedStepResult(s, context.Canceled)
+	assert.Len(t, r.Failed(), 2)
+	assert.Equal(t, context.Canceled, Cause(r.Err()))
+}
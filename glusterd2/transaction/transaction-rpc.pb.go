@@ -35,8 +35,10 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type TxnStepReq struct {
-	StepFunc string `protobuf:"bytes,1,opt,name=StepFunc" json:"StepFunc,omitempty"`
-	Context  []byte `protobuf:"bytes,2,opt,name=Context,proto3" json:"Context,omitempty"`
+	StepFunc string            `protobuf:"bytes,1,opt,name=StepFunc" json:"StepFunc,omitempty"`
+	Context  []byte            `protobuf:"bytes,2,opt,name=Context,proto3" json:"Context,omitempty"`
+	Inline   bool              `protobuf:"varint,3,opt,name=Inline" json:"Inline,omitempty"`
+	Data     map[string][]byte `protobuf:"bytes,4,rep,name=Data" json:"Data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *TxnStepReq) Reset()                    { *m = TxnStepReq{} }
@@ -58,9 +60,25 @@ func (m *TxnStepReq) GetContext() []byte {
 	return nil
 }
 
+func (m *TxnStepReq) GetInline() bool {
+	if m != nil {
+		return m.Inline
+	}
+	return false
+}
+
+func (m *TxnStepReq) GetData() map[string][]byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 type TxnStepResp struct {
-	Error string `protobuf:"bytes,1,opt,name=Error" json:"Error,omitempty"`
-	Resp  []byte `protobuf:"bytes,2,opt,name=Resp,proto3" json:"Resp,omitempty"`
+	Error   string            `protobuf:"bytes,1,opt,name=Error" json:"Error,omitempty"`
+	Resp    []byte            `protobuf:"bytes,2,opt,name=Resp,proto3" json:"Resp,omitempty"`
+	Data    map[string][]byte `protobuf:"bytes,3,rep,name=Data" json:"Data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Deleted []string          `protobuf:"bytes,4,rep,name=Deleted" json:"Deleted,omitempty"`
 }
 
 func (m *TxnStepResp) Reset()                    { *m = TxnStepResp{} }
@@ -82,6 +100,20 @@ func (m *TxnStepResp) GetResp() []byte {
 	return nil
 }
 
+func (m *TxnStepResp) GetData() map[string][]byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *TxnStepResp) GetDeleted() []string {
+	if m != nil {
+		return m.Deleted
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*TxnStepReq)(nil), "transaction.TxnStepReq")
 	proto.RegisterType((*TxnStepResp)(nil), "transaction.TxnStepResp")
@@ -162,16 +194,23 @@ var _TxnSvc_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("transaction/transaction-rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 174 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x52, 0x2c, 0x29, 0x4a, 0xcc,
-	0x2b, 0x4e, 0x4c, 0x2e, 0xc9, 0xcc, 0xcf, 0xd3, 0x47, 0x62, 0xeb, 0x16, 0x15, 0x24, 0xeb, 0x15,
-	0x14, 0xe5, 0x97, 0xe4, 0x0b, 0x71, 0x23, 0x09, 0x2b, 0x39, 0x71, 0x71, 0x85, 0x54, 0xe4, 0x05,
-	0x97, 0xa4, 0x16, 0x04, 0xa5, 0x16, 0x0a, 0x49, 0x71, 0x71, 0x80, 0x98, 0x6e, 0xa5, 0x79, 0xc9,
-	0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x70, 0xbe, 0x90, 0x04, 0x17, 0xbb, 0x73, 0x7e, 0x5e,
-	0x49, 0x6a, 0x45, 0x89, 0x04, 0x13, 0x50, 0x8a, 0x27, 0x08, 0xc6, 0x55, 0x32, 0xe7, 0xe2, 0x86,
-	0x9b, 0x51, 0x5c, 0x20, 0x24, 0xc2, 0xc5, 0xea, 0x5a, 0x54, 0x94, 0x5f, 0x04, 0x35, 0x01, 0xc2,
-	0x11, 0x12, 0xe2, 0x62, 0x01, 0xc9, 0x42, 0xf5, 0x82, 0xd9, 0x46, 0x1e, 0x5c, 0x6c, 0x20, 0x8d,
-	0x65, 0xc9, 0x42, 0x76, 0x5c, 0xec, 0x41, 0xa5, 0x60, 0x23, 0x84, 0xc4, 0xf5, 0x90, 0xdc, 0xa7,
-	0x87, 0x70, 0x9c, 0x94, 0x04, 0x76, 0x89, 0xe2, 0x02, 0x25, 0x86, 0x24, 0x36, 0xb0, 0xd7, 0x8c,
-	0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0xc6, 0x0b, 0xfc, 0x47, 0xff, 0x00, 0x00, 0x00,
+	// 277 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x9d, 0x51, 0x3d, 0x4f, 0xc3, 0x30,
+	0x10, 0x25, 0x4d, 0x48, 0x9b, 0x0b, 0x03, 0x3a, 0x21, 0xb0, 0x32, 0xb5, 0x99, 0xba, 0x10, 0xa4,
+	0x22, 0x3e, 0xc4, 0xc0, 0x42, 0x8b, 0x60, 0x35, 0xfc, 0x01, 0x13, 0x3c, 0x54, 0x44, 0xb6, 0x71,
+	0x9c, 0xaa, 0xfd, 0x7b, 0x1d, 0xf9, 0x55, 0xd4, 0xae, 0x1b, 0x22, 0x44, 0x17, 0xb6, 0xf7, 0xee,
+	0xdd, 0xf9, 0xbd, 0x3b, 0xc3, 0xc8, 0x68, 0x26, 0x6a, 0x56, 0x9a, 0xb9, 0x14, 0x17, 0x1d, 0x7c,
+	0xae, 0x55, 0x59, 0x28, 0x2d, 0x8d, 0xc4, 0xb4, 0x53, 0xce, 0xbf, 0x02, 0x80, 0xd7, 0xa5, 0x78,
+	0x31, 0x5c, 0x51, 0xfe, 0x89, 0x19, 0x0c, 0x2c, 0x7c, 0x6c, 0x44, 0x49, 0x82, 0x61, 0x30, 0x4e,
+	0x68, 0xcb, 0x91, 0x40, 0xff, 0x41, 0x0a, 0xc3, 0x97, 0x86, 0xf4, 0x36, 0xd2, 0x11, 0xdd, 0x51,
+	0x3c, 0x85, 0xf8, 0x59, 0x54, 0x73, 0xc1, 0x49, 0xb8, 0x11, 0x06, 0xd4, 0x33, 0xbc, 0x82, 0x68,
+	0xca, 0x0c, 0x23, 0xd1, 0x30, 0x1c, 0xa7, 0x93, 0x51, 0xd1, 0x31, 0x2e, 0x7e, 0x4c, 0x0b, 0xdb,
+	0x33, 0x13, 0x46, 0xaf, 0xa8, 0x6b, 0xcf, 0x6e, 0x20, 0x69, 0x4b, 0x78, 0x0c, 0xe1, 0x07, 0x5f,
+	0xf9, 0x30, 0x16, 0xe2, 0x09, 0x1c, 0x2e, 0x58, 0xd5, 0x70, 0x9f, 0x62, 0x4b, 0xee, 0x7a, 0xb7,
+	0x41, 0xbe, 0x0e, 0x20, 0x6d, 0xdf, 0xad, 0x95, 0xed, 0x9c, 0x69, 0x2d, 0xb5, 0x9f, 0xde, 0x12,
+	0x44, 0x88, 0xac, 0xea, 0xc7, 0x1d, 0xc6, 0x6b, 0x9f, 0x34, 0x74, 0x49, 0xf3, 0xbf, 0x93, 0xd6,
+	0xea, 0x77, 0x54, 0x7b, 0x93, 0x29, 0xaf, 0xb8, 0xe1, 0xef, 0x6e, 0xc9, 0x84, 0xee, 0xe8, 0xbf,
+	0x97, 0x98, 0x3c, 0x41, 0x6c, 0x1d, 0x17, 0x25, 0xde, 0x43, 0x9f, 0x36, 0xce, 0x1b, 0xcf, 0xf6,
+	0xdc, 0x2e, 0x23, 0xfb, 0xa2, 0xe6, 0x07, 0x6f, 0xb1, 0xfb, 0xef, 0xcb, 0x6f, 0x2b, 0x81, 0xf9,
+	0x9a, 0x14, 0x02, 0x00, 0x00,
 }
@@ -4,10 +4,14 @@ package transaction
 import (
 	"context"
 	"expvar"
-	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/health"
 	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/glusterd2/webhook"
+	"github.com/gluster/glusterd2/pkg/api"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/pborman/uuid"
@@ -18,7 +22,12 @@ const (
 	txnPrefix = store.GlusterPrefix + "transaction/"
 )
 
-var expTxn = expvar.NewMap("txn")
+var (
+	expTxn = expvar.NewMap("txn")
+
+	// notifyFunc sends the events of completed transactions
+	notifyFunc = webhook.Notify
+)
 
 // Txn is a set of steps
 type Txn struct {
@@ -29,55 +38,284 @@ type Txn struct {
 	// before calling Txn.Do(). This is currently only used to determine
 	// liveness of the nodes before running the transaction steps.
 	Nodes []uuid.UUID
+	// Results holds the per-node results of the steps run by Txn.Do(),
+	// indexed by step. The results of steps that were not run are nil.
+	Results []*StepResult
+	// Recovery determines how the transaction is recovered if this node
+	// restarts before the transaction is complete.
+	Recovery RecoveryPolicy
+	// User is the authenticated user who started the transaction, if any.
+	// It is recorded in the transaction history.
+	User string
+	// DryRun, if set, makes Txn.Do() run only the validation steps of the
+	// transaction, so that nothing is changed in the cluster.
+	DryRun bool
+
+	job *Job
 }
 
-// NewTxn returns an initialized Txn without any steps
+// NewTxn returns an initialized Txn without any steps.
+// The transaction is aborted and rolled back if the given ctx, usually the
+// context of the originating request, is cancelled while it is running.
 func NewTxn(ctx context.Context) *Txn {
 	t := new(Txn)
 	t.ID = uuid.Parse(ctx.Value(gdctx.ReqIDKey).(string))
 	prefix := txnPrefix + t.ID.String()
 	t.Ctx = NewCtxWithLogFields(log.Fields{
 		"reqid": t.ID.String(),
-	}).WithPrefix(prefix)
+	}).WithPrefix(prefix).WithContext(ctx)
+
+	if user, ok := ctx.Value(gdctx.ReqUserKey).(string); ok {
+		t.User = user
+	}
+
+	return t
+}
 
+// NewTxnWithInlineCtx returns an initialized Txn like NewTxn, whose Ctx is an
+// InlineCtx. The data set in the Ctx is sent to the nodes along with the steps
+// instead of being stored in the store, which is cheaper for transactions with
+// steps run on many nodes.
+func NewTxnWithInlineCtx(ctx context.Context) *Txn {
+	t := NewTxn(ctx)
+	t.Ctx = NewInlineCtx(t.Ctx.(*Tctx))
 	return t
 }
 
 // Cleanup cleans the leftovers after a transaction ends
 func (t *Txn) Cleanup() {
+	if t.job != nil {
+		// Transactions run with DoAsync() clean up when they complete
+		return
+	}
+	t.cleanup()
+}
+
+func (t *Txn) cleanup() {
 	store.Store.Delete(context.TODO(), t.Ctx.Prefix(), clientv3.WithPrefix())
-	expTxn.Add("initiated_txn_in_progress", -1)
+	t.deleteJournal()
 }
 
-// Do runs the transaction on the cluster
-func (t *Txn) Do() (TxnCtx, error) {
+// Do runs the transaction on the cluster.
+// If a step fails on any node, the returned error is a *StepError containing
+// the results of the step on each of its nodes.
+// The outcome of the transaction is recorded in the transaction history.
+func (t *Txn) Do() (c TxnCtx, err error) {
 	t.Ctx.Logger().Debug("Starting transaction")
 
+	started := time.Now()
+	defer func() {
+		t.saveHistory(started, err)
+		if !t.DryRun {
+			t.notify(err)
+		}
+	}()
+
+	if t.DryRun {
+		t.Steps = dryRunSteps(t.Steps)
+	}
+
+	if err := validateSteps(t.Steps); err != nil {
+		return nil, err
+	}
+
+	if err := checkOpVersions(t.Steps); err != nil {
+		return nil, err
+	}
+
 	// verify that all nodes are online
 	for _, node := range t.Nodes {
-		if !store.Store.IsNodeAlive(node) {
-			return nil, fmt.Errorf("node %s is probably down", node.String())
+		if err := health.Check(node); err != nil {
+			return nil, err
 		}
 	}
 
+	// Only transactions whose steps are run are counted as in progress
 	expTxn.Add("initiated_txn_in_progress", 1)
+	defer expTxn.Add("initiated_txn_in_progress", -1)
 
-	//Do the steps
-	for i, s := range t.Steps {
-		if e := s.do(t.Ctx); e != nil {
-			t.Ctx.Logger().WithError(e).Error("Transaction failed, rolling back changes")
-			t.undo(i)
-			return nil, e
+	if err := t.saveJournal(0, journalRunning); err != nil {
+		t.Ctx.Logger().WithError(err).Error("failed to save transaction journal")
+		return nil, err
+	}
+
+	return t.do(0)
+}
+
+// do runs the steps of the transaction starting from the given step, and
+// journals the progress. The steps are run in groups, see stepGroupEnd().
+func (t *Txn) do(from int) (TxnCtx, error) {
+	if t.Results == nil {
+		t.Results = make([]*StepResult, len(t.Steps))
+	}
+
+	// The locks of the transaction are lost with the store session, so the
+	// steps are cancelled when it ends, and the transaction is rolled back
+	c := t.Ctx
+	ctx, cancel := withSession(c.Context())
+	t.Ctx = withContext(c, ctx)
+	defer func() {
+		cancel()
+		t.Ctx = c
+	}()
+
+	for start := from; start < len(t.Steps); {
+		end := t.stepGroupEnd(start)
+		t.runStepGroup(start, end)
+		if r := t.stepGroupFailure(start, end); r != nil {
+			t.Ctx.Logger().WithError(r.Err()).Error("Transaction failed, rolling back changes")
+			t.updateJournal(start, journalRollingBack)
+			undoFailures := t.undo(end - 1)
+			t.updateJournal(start, journalDone)
+			return nil, &StepError{StepResult: r, UndoFailures: undoFailures}
+		}
+		start = end
+		t.updateJournal(start, journalRunning)
+	}
+	t.updateJournal(len(t.Steps), journalDone)
+
+	return c, nil
+}
+
+// sessionContext is a context.Context which is cancelled with
+// store.ErrSessionLost when the store session it was created with ends
+type sessionContext struct {
+	context.Context
+	sessionDone <-chan struct{}
+}
+
+func (c *sessionContext) Err() error {
+	err := c.Context.Err()
+	if err == context.Canceled {
+		select {
+		case <-c.sessionDone:
+			return store.ErrSessionLost
+		default:
+		}
+	}
+	return err
+}
+
+// withSession returns a context.Context derived from ctx, which is cancelled
+// when the current store session ends
+func withSession(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	sessionDone := store.Store.Session().Done()
+	go func() {
+		select {
+		case <-sessionDone:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return &sessionContext{ctx, sessionDone}, cancel
+}
+
+// notify sends the transaction-succeeded or transaction-failed event for the
+// transaction to the webhooks. The volume of the event is set if the
+// transaction locked a single volume.
+func (t *Txn) notify(err error) {
+	e := api.Event{
+		Type:        api.EventTransactionSucceeded,
+		Transaction: t.ID.String(),
+		Resources:   t.resources(),
+		Peer:        gdctx.MyUUID.String(),
+	}
+	if err != nil {
+		e.Type = api.EventTransactionFailed
+		e.Error = err.Error()
+	}
+
+	volPrefix := VolumeLockKey("")
+	for _, r := range e.Resources {
+		if !strings.HasPrefix(r, volPrefix) {
+			continue
+		}
+		volname := strings.SplitN(strings.TrimPrefix(r, volPrefix), "/", 2)[0]
+		if e.Volume != "" && e.Volume != volname {
+			e.Volume = ""
+			break
+		}
+		e.Volume = volname
+	}
+
+	notifyFunc(e)
+}
+
+// dryRunSteps returns the steps which are run by a dry run of a transaction
+// with the given steps. Dependencies on the steps which are not run are dropped.
+func dryRunSteps(steps []*Step) []*Step {
+	var dry []*Step
+	index := make(map[int]int)
+	for i, s := range steps {
+		if !s.Validate && !isLockStep(s) {
+			continue
 		}
+		index[i] = len(dry)
+		if len(s.DependsOn) != 0 {
+			c := *s
+			c.DependsOn = nil
+			for _, d := range s.DependsOn {
+				if n, ok := index[d]; ok {
+					c.DependsOn = append(c.DependsOn, n)
+				}
+			}
+			s = &c
+		}
+		dry = append(dry, s)
 	}
+	return dry
+}
 
-	return t.Ctx, nil
+// ValidationResults returns the results of the validation steps which were
+// run by Txn.Do(). These are the verdict of a dry run.
+func (t *Txn) ValidationResults() []*StepResult {
+	results := make([]*StepResult, 0)
+	for i, s := range t.Steps {
+		if s.Validate && i < len(t.Results) && t.Results[i] != nil {
+			results = append(results, t.Results[i])
+		}
+	}
+	return results
 }
 
-// undo undoes a transaction and will be automatically called by Perform if any step fails.
-// The Steps are undone in the reverse order, from the failed step.
-func (t *Txn) undo(n int) {
+// undo undoes a transaction and will be automatically called by Do if any step fails.
+// The Steps are undone in the reverse order, from the given step, and only on
+// the nodes where the step succeeded. As steps only depend on the steps before
+// them, parallel steps are always undone before the steps they depend on. The results of the UndoFuncs that failed
+// are returned.
+func (t *Txn) undo(n int) []*StepResult {
+	// The transaction may have failed because it was cancelled, so the undo
+	// steps must not use the transaction's context.Context
+	c := withContext(t.Ctx, context.Background())
+
+	var failures []*StepResult
 	for i := n; i >= 0; i-- {
-		t.Steps[i].undo(t.Ctx)
+		r := t.Steps[i].undo(c, t.undoNodes(i))
+		if r == nil {
+			continue
+		}
+		if e := r.Err(); e != nil {
+			t.Ctx.Logger().WithError(e).Error("failed to undo step")
+			failures = append(failures, r)
+		}
+	}
+	return failures
+}
+
+// undoNodes returns the nodes on which the given step needs to be undone
+func (t *Txn) undoNodes(i int) []uuid.UUID {
+	if i >= len(t.Results) || t.Results[i] == nil {
+		// The step was being run when the results were lost, so it is not
+		// known where it succeeded. Undo it on all the nodes.
+		return t.Steps[i].Nodes
+	}
+
+	var nodes []uuid.UUID
+	for _, n := range t.Results[i].Nodes {
+		if n.Success {
+			nodes = append(nodes, n.NodeID)
+		}
 	}
+	return nodes
 }
@@ -0,0 +1,172 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/testutils"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTxnUndo validates that steps are only undone on nodes where they succeeded
+func TestTxnUndo(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+
+	var undone []string
+	RegisterStepFunc(func(TxnCtx) error {
+		undone = append(undone, "first")
+		return nil
+	}, "test-undo.First")
+	RegisterStepFunc(func(TxnCtx) error {
+		undone = append(undone, "second")
+		return errors.New("undo failed")
+	}, "test-undo.Second")
+
+	nodes := []uuid.UUID{gdctx.MyUUID}
+	txn := &Txn{
+		Ctx: NewMockCtx(),
+		Steps: []*Step{
+			{DoFunc: "test-undo.Do", UndoFunc: "test-undo.First", Nodes: nodes},
+			{DoFunc: "test-undo.Do", UndoFunc: "test-undo.Second", Nodes: nodes},
+			{DoFunc: "test-undo.Do", UndoFunc: "test-undo.First", Nodes: nodes},
+		},
+		Results: []*StepResult{
+			{StepFunc: "test-undo.Do", Nodes: []NodeResult{{NodeID: gdctx.MyUUID, Success: true}}},
+			{StepFunc: "test-undo.Do", Nodes: []NodeResult{{NodeID: gdctx.MyUUID, Success: true}}},
+			{StepFunc: "test-undo.Do", Nodes: []NodeResult{{NodeID: gdctx.MyUUID, Success: false}}},
+		},
+	}
+
+	failures := txn.undo(2)
+	assert.Equal(t, []string{"second", "first"}, undone)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, "test-undo.Second", failures[0].StepFunc)
+
+	// Steps without results are undone on all nodes
+	undone = nil
+	txn.Results = nil
+	txn.undo(0)
+	assert.Equal(t, []string{"first"}, undone)
+}
+
+// TestDryRunSteps validates that dry runs only run validation and lock steps
+func TestDryRunSteps(t *testing.T) {
+	lock, unlock, err := CreateLockSteps(VolumeLockKey("vol1"))
+	assert.Nil(t, err)
+	validate := &Step{DoFunc: "test-dryrun.Validate", Validate: true}
+	commit := &Step{DoFunc: "test-dryrun.Commit", UndoFunc: "test-dryrun.Undo"}
+
+	steps := dryRunSteps([]*Step{lock, validate, commit, unlock})
+	assert.Equal(t, []*Step{lock, validate, unlock}, steps)
+
+	// Dependencies are kept on the steps that are run
+	check := &Step{DoFunc: "test-dryrun.Check", Validate: true, Parallel: true, DependsOn: []int{1, 2}}
+	steps = dryRunSteps([]*Step{lock, commit, validate, check, unlock})
+	assert.Equal(t, []int{1}, steps[2].DependsOn)
+	assert.Equal(t, []int{1, 2}, check.DependsOn)
+
+	txn := &Txn{
+		Steps:   steps,
+		Results: []*StepResult{{StepFunc: lock.DoFunc}, {StepFunc: validate.DoFunc}, nil},
+	}
+	results := txn.ValidationResults()
+	assert.Len(t, results, 1)
+	assert.Equal(t, validate.DoFunc, results[0].StepFunc)
+}
+
+// TestTxnNotify validates the events sent for completed transactions
+func TestTxnNotify(t *testing.T) {
+	gdctx.MyUUID = uuid.NewRandom()
+
+	var events []api.Event
+	defer testutils.Patch(&notifyFunc, func(e api.Event) {
+		events = append(events, e)
+	}).Restore()
+
+	lock, unlock, err := CreateLockSteps(BrickLockKey("vol1", "host1:/bricks/b1"))
+	assert.Nil(t, err)
+	volLock, volUnlock, err := CreateLockSteps(VolumeLockKey("vol1"))
+	assert.Nil(t, err)
+	txn := &Txn{
+		ID:    uuid.NewRandom(),
+		Steps: []*Step{lock, volLock, {DoFunc: "test-notify.Do"}, volUnlock, unlock},
+	}
+
+	txn.notify(nil)
+	txn.notify(errors.New("step failed"))
+	expected := []api.Event{
+		{
+			Type:        api.EventTransactionSucceeded,
+			Transaction: txn.ID.String(),
+			Resources:   []string{BrickLockKey("vol1", "host1:/bricks/b1"), VolumeLockKey("vol1")},
+			Volume:      "vol1",
+			Peer:        gdctx.MyUUID.String(),
+		},
+		{
+			Type:        api.EventTransactionFailed,
+			Transaction: txn.ID.String(),
+			Resources:   []string{BrickLockKey("vol1", "host1:/bricks/b1"), VolumeLockKey("vol1")},
+			Volume:      "vol1",
+			Peer:        gdctx.MyUUID.String(),
+			Error:       "step failed",
+		},
+	}
+	assert.Equal(t, expected, events)
+
+	// The volume isn't set for transactions locking several volumes
+	events = nil
+	other, _, err := CreateLockSteps(VolumeLockKey("vol2"))
+	assert.Nil(t, err)
+	txn.Steps = []*Step{volLock, other}
+	txn.notify(nil)
+	if assert.Len(t, events, 1) {
+		assert.Empty(t, events[0].Volume)
+	}
+}
+
+// TestTxnSessionLost validates that running transactions are cancelled and
+// rolled back when the store session ends
+func TestTxnSessionLost(t *testing.T) {
+	defer initTestStore(t)()
+
+	var undone bool
+	RegisterStepFunc(func(c TxnCtx) error {
+		lease := store.Store.Session().Lease()
+		if _, err := store.Store.Revoke(context.Background(), lease); err != nil {
+			return err
+		}
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-time.After(10 * time.Second):
+			return errors.New("step not cancelled")
+		}
+	}, "test-session.Do")
+	RegisterStepFunc(func(TxnCtx) error {
+		undone = true
+		return nil
+	}, "test-session.Undo")
+
+	nodes := []uuid.UUID{gdctx.MyUUID}
+	txn := NewTxn(context.WithValue(context.Background(), gdctx.ReqIDKey, uuid.New()))
+	defer txn.Cleanup()
+	txn.Steps = []*Step{
+		{DoFunc: "test-session.Do", UndoFunc: "test-session.Undo", Nodes: nodes},
+		{DoFunc: "test-session.Next", Nodes: nodes},
+	}
+	txn.Nodes = nodes
+
+	_, err := txn.do(0)
+	assert.Equal(t, store.ErrSessionLost, Cause(err))
+	assert.True(t, undone)
+	assert.Nil(t, txn.Results[1])
+	// The request context of the transaction isn't cancelled
+	assert.Nil(t, txn.Ctx.Context().Err())
+}
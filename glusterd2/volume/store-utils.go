@@ -13,7 +13,8 @@ import (
 )
 
 const (
-	volumePrefix string = store.GlusterPrefix + "volumes/"
+	// StorePrefix is the prefix under which volumes are saved in the store
+	StorePrefix string = store.GlusterPrefix + "volumes/"
 )
 
 var (
@@ -31,7 +32,7 @@ func AddOrUpdateVolume(v *Volinfo) error {
 		return e
 	}
 
-	_, e = store.Store.Put(context.TODO(), volumePrefix+v.Name, string(json))
+	_, e = store.Store.Put(context.TODO(), StorePrefix+v.Name, string(json))
 	if e != nil {
 		log.WithError(e).Error("Couldn't add volume to store")
 		return e
@@ -43,7 +44,7 @@ func AddOrUpdateVolume(v *Volinfo) error {
 // volinfo object
 func GetVolume(name string) (*Volinfo, error) {
 	var v Volinfo
-	resp, e := store.Store.Get(context.TODO(), volumePrefix+name)
+	resp, e := store.Store.Get(context.TODO(), StorePrefix+name)
 	if e != nil {
 		log.WithError(e).Error("Couldn't retrive volume from store")
 		return nil, e
@@ -63,13 +64,13 @@ func GetVolume(name string) (*Volinfo, error) {
 
 //DeleteVolume passes the volname to store to delete the volume object
 func DeleteVolume(name string) error {
-	_, e := store.Store.Delete(context.TODO(), volumePrefix+name)
+	_, e := store.Store.Delete(context.TODO(), StorePrefix+name)
 	return e
 }
 
 // GetVolumesList returns a map of volume names to their UUIDs
 func GetVolumesList() (map[string]uuid.UUID, error) {
-	resp, e := store.Store.Get(context.TODO(), volumePrefix, clientv3.WithPrefix())
+	resp, e := store.Store.Get(context.TODO(), StorePrefix, clientv3.WithPrefix())
 	if e != nil {
 		return nil, e
 	}
@@ -96,7 +97,7 @@ func GetVolumesList() (map[string]uuid.UUID, error) {
 //GetVolumes retrives the json objects from the store and converts them into
 //respective volinfo objects
 func GetVolumes() ([]*Volinfo, error) {
-	resp, e := store.Store.Get(context.TODO(), volumePrefix, clientv3.WithPrefix())
+	resp, e := store.Store.Get(context.TODO(), StorePrefix, clientv3.WithPrefix())
 	if e != nil {
 		return nil, e
 	}
@@ -122,7 +123,7 @@ func GetVolumes() ([]*Volinfo, error) {
 
 //Exists check whether a given volume exist or not
 func Exists(name string) bool {
-	resp, e := store.Store.Get(context.TODO(), volumePrefix+name)
+	resp, e := store.Store.Get(context.TODO(), StorePrefix+name)
 	if e != nil {
 		return false
 	}
@@ -133,8 +133,8 @@ func NewBrickEntries(bricks []string, volName string, volID uuid.UUID) ([]brick.
 	return brickInfos, nil
 }
 
-// ValidateBrickEntries validates the brick list
-func ValidateBrickEntries(bricks []brick.Brickinfo, volID uuid.UUID, force bool) (int, error) {
+// ValidateBrickEntries validates the brick list. The bricks aren't changed.
+func ValidateBrickEntries(bricks []brick.Brickinfo, force bool) (int, error) {
 
 	var err error
 	for _, b := range bricks {
@@ -158,7 +158,7 @@ func ValidateBrickEntries(bricks []brick.Brickinfo, volID uuid.UUID, force bool)
 		if err != nil {
 			return http.StatusBadRequest, err
 		}
-		err = utils.ValidateXattrSupport(b.Path, volID, force)
+		err = utils.ValidateXattrSupport(b.Path, force)
 		if err != nil {
 			return http.StatusBadRequest, err
 		}
@@ -0,0 +1,317 @@
+// Package webhook implements pushing cluster events to registered HTTP
+// webhooks
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxAttempts is the number of times an event is sent to a webhook
+	// before giving up
+	maxAttempts = 5
+	// requestTimeout is the time allowed for a webhook to respond
+	requestTimeout = 10 * time.Second
+	// queueSize is the number of events which can wait to be sent to a
+	// webhook. Events sent to a webhook whose queue is full are dropped.
+	queueSize = 100
+)
+
+var (
+	// retryInterval is the time waited before the first retry of a failed
+	// delivery. It doubles after every attempt.
+	retryInterval = time.Second
+	// watchRetryInterval is the time waited before reloading the webhooks
+	// when watching them fails
+	watchRetryInterval = 5 * time.Second
+
+	client = &http.Client{Timeout: requestTimeout}
+)
+
+// worker sends the events queued for a webhook, one at a time
+type worker struct {
+	w      *Webhook
+	events chan *api.Event
+	cancel context.CancelFunc
+}
+
+// workers are the workers of the registered webhooks, by webhook ID. They
+// are kept up to date with the store by Start.
+var workers = struct {
+	sync.RWMutex
+	m map[string]*worker
+}{m: make(map[string]*worker)}
+
+// Notify queues the event to be sent to all the webhooks which want it. The
+// events are sent in the background, and failed deliveries are retried, so
+// Notify doesn't block the caller. Events are only sent once Start has
+// loaded the webhooks.
+func Notify(e api.Event) {
+	workers.RLock()
+	defer workers.RUnlock()
+
+	for _, wk := range workers.m {
+		if !wk.w.Wants(&e) {
+			continue
+		}
+		select {
+		case wk.events <- &e:
+		default:
+			log.WithFields(log.Fields{
+				"webhook": wk.w.ID.String(),
+				"event":   e.Type,
+			}).Warning("webhook queue is full, dropping event")
+		}
+	}
+}
+
+// Start keeps a worker running for every registered webhook, until ctx is
+// cancelled. The webhooks are loaded from the store, and then watched for
+// changes.
+func Start(ctx context.Context) {
+	defer setWorkers(ctx, nil)
+
+	for {
+		rev, err := loadWebhooks(ctx)
+		if err == nil {
+			err = watchWebhooks(ctx, rev)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Error("failed to watch webhooks, retrying")
+
+		select {
+		case <-time.After(watchRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// loadWebhooks starts the workers of the registered webhooks, and stops the
+// others. It returns the store revision the webhooks were loaded at.
+func loadWebhooks(ctx context.Context) (int64, error) {
+	resp, err := store.Store.Get(ctx, StorePrefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := make([]*Webhook, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var w Webhook
+		if err := json.Unmarshal(kv.Value, &w); err != nil {
+			return 0, err
+		}
+		webhooks = append(webhooks, &w)
+	}
+	setWorkers(ctx, webhooks)
+
+	return resp.Header.Revision, nil
+}
+
+// watchWebhooks updates the workers with the changes of the webhooks made
+// after the given revision. It returns when the watch fails or ctx is
+// cancelled.
+func watchWebhooks(ctx context.Context, rev int64) error {
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	for wresp := range store.Store.Watch(wctx, StorePrefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+		if err := wresp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range wresp.Events {
+			if ev.Type == mvccpb.DELETE {
+				removeWorker(strings.TrimPrefix(string(ev.Kv.Key), StorePrefix))
+				continue
+			}
+
+			var w Webhook
+			if err := json.Unmarshal(ev.Kv.Value, &w); err != nil {
+				log.WithError(err).WithField("key", string(ev.Kv.Key)).Error("failed to unmarshal webhook")
+				continue
+			}
+			addWorker(ctx, &w)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.New("webhook watch closed")
+}
+
+// setWorkers replaces the workers with the workers of the given webhooks
+func setWorkers(ctx context.Context, webhooks []*Webhook) {
+	ids := make(map[string]bool, len(webhooks))
+	for _, w := range webhooks {
+		ids[w.ID.String()] = true
+		addWorker(ctx, w)
+	}
+
+	workers.Lock()
+	defer workers.Unlock()
+	for id, wk := range workers.m {
+		if !ids[id] {
+			wk.cancel()
+			delete(workers.m, id)
+		}
+	}
+}
+
+// addWorker starts a worker for the webhook, replacing the worker of the
+// previous version of the webhook if it has changed
+func addWorker(ctx context.Context, w *Webhook) {
+	workers.Lock()
+	defer workers.Unlock()
+
+	id := w.ID.String()
+	if wk, ok := workers.m[id]; ok {
+		if reflect.DeepEqual(wk.w, w) {
+			return
+		}
+		wk.cancel()
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	wk := &worker{w: w, events: make(chan *api.Event, queueSize), cancel: cancel}
+	workers.m[id] = wk
+	go wk.run(wctx)
+}
+
+// removeWorker stops the worker of the webhook with the given ID
+func removeWorker(id string) {
+	workers.Lock()
+	defer workers.Unlock()
+
+	if wk, ok := workers.m[id]; ok {
+		wk.cancel()
+		delete(workers.m, id)
+	}
+}
+
+// run sends the queued events until ctx is cancelled. The events still queued
+// then are dropped.
+func (wk *worker) run(ctx context.Context) {
+	for {
+		select {
+		case e := <-wk.events:
+			deliver(ctx, wk.w, e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sign returns the signature of the body sent to a webhook with the given
+// secret, as sent in the X-Gluster-Signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver sends the event to the webhook, retrying with an exponential
+// backoff on failures until ctx is cancelled
+func deliver(ctx context.Context, w *Webhook, e *api.Event) error {
+	logger := log.WithFields(log.Fields{
+		"webhook": w.ID.String(),
+		"url":     w.URL,
+		"event":   e.Type,
+	})
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("failed to marshal event")
+		return err
+	}
+
+	wait := retryInterval
+	for attempt := 1; ; attempt++ {
+		err = send(ctx, w, e, body)
+		if err == nil {
+			return nil
+		}
+		if attempt == maxAttempts || ctx.Err() != nil {
+			logger.WithError(err).Error("failed to send event to webhook, giving up")
+			return err
+		}
+
+		logger.WithError(err).WithField("attempt", attempt).Debug("failed to send event to webhook, retrying")
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			logger.WithError(ctx.Err()).Error("failed to send event to webhook, giving up")
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+func send(ctx context.Context, w *Webhook, e *api.Event, body []byte) error {
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Gluster-Event", string(e.Type))
+	if w.Secret != "" {
+		req.Header.Set("X-Gluster-Signature", Sign(w.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// WatchLiveness sends peer-online and peer-offline events when peers become
+// alive or go down. Every peer sees the changes, but only the alive peer with
+// the lowest ID sends the events, so that webhooks receive them once.
+func WatchLiveness(ctx context.Context) {
+	store.Store.WatchLiveness(ctx, func(nodeID string, alive bool) {
+		nodes, err := store.Store.AliveNodes()
+		if err != nil {
+			log.WithError(err).Error("failed to get alive nodes")
+			return
+		}
+		for _, n := range nodes {
+			if n < gdctx.MyUUID.String() {
+				return
+			}
+		}
+
+		typ := api.EventPeerOffline
+		if alive {
+			typ = api.EventPeerOnline
+		}
+		Notify(api.Event{Type: typ, Peer: nodeID})
+	})
+}
@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gluster/glusterd2/glusterd2/gdctx"
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWants validates the event filters of webhooks
+func TestWants(t *testing.T) {
+	all := &Webhook{}
+	assert.True(t, all.Wants(&api.Event{Type: api.EventVolumeCreated}))
+
+	filtered := &Webhook{Events: []api.EventType{api.EventPeerOffline, api.EventDaemonExited}}
+	assert.True(t, filtered.Wants(&api.Event{Type: api.EventDaemonExited}))
+	assert.False(t, filtered.Wants(&api.Event{Type: api.EventVolumeCreated}))
+}
+
+// TestDeliver validates that events are signed and that failed deliveries
+// are retried, using a local HTTP listener as the webhook
+func TestDeliver(t *testing.T) {
+	defer func(d time.Duration) {
+		retryInterval = d
+	}(retryInterval)
+	retryInterval = time.Millisecond
+
+	var requests int
+	received := make(chan api.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, Sign("secret", body), r.Header.Get("X-Gluster-Signature"))
+		assert.Equal(t, string(api.EventDaemonExited), r.Header.Get("X-Gluster-Event"))
+
+		var e api.Event
+		assert.Nil(t, json.Unmarshal(body, &e))
+		received <- e
+	}))
+	defer srv.Close()
+
+	w := &Webhook{ID: uuid.NewRandom(), URL: srv.URL, Secret: "secret"}
+	e := &api.Event{Type: api.EventDaemonExited, Daemon: "glusterfsd"}
+	assert.Nil(t, deliver(context.Background(), w, e))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, *e, <-received)
+
+	// Deliveries to webhooks which keep failing are given up
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	assert.NotNil(t, deliver(context.Background(), w, e))
+
+	// Retries stop once the delivery is cancelled
+	retryInterval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- deliver(ctx, w, e)
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		assert.NotNil(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("delivery not cancelled")
+	}
+}
+
+// initTestStore starts an embedded store for the tests which need one
+func initTestStore(t *testing.T) func() {
+	if testing.Short() {
+		t.Skip("skipping embedded store test in short mode")
+	}
+
+	gdctx.MyUUID = uuid.NewRandom()
+	dir, err := ioutil.TempDir("", "gd2webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	destroy, err := store.InitTestStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return func() {
+		destroy()
+		os.RemoveAll(dir)
+	}
+}
+
+// hasWorker returns true if a worker is running for the webhook with the
+// given ID
+func hasWorker(id string) bool {
+	workers.RLock()
+	defer workers.RUnlock()
+	_, ok := workers.m[id]
+	return ok
+}
+
+// waitWorker waits until a worker is running for the webhook with the given
+// ID, or not
+func waitWorker(t *testing.T, id string, running bool) {
+	for i := 0; hasWorker(id) != running; i++ {
+		if i == 100 {
+			t.Fatalf("timed out waiting for the worker of webhook %s", id)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// TestStart validates that events are sent to the webhooks added after the
+// workers are started, and not to the deleted ones
+func TestStart(t *testing.T) {
+	defer initTestStore(t)()
+
+	received := make(chan api.Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e api.Event
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&e))
+		received <- e
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go Start(ctx)
+
+	wh := &Webhook{ID: uuid.NewRandom(), URL: srv.URL}
+	assert.Nil(t, Add(wh))
+	waitWorker(t, wh.ID.String(), true)
+
+	e := api.Event{Type: api.EventDaemonExited, Daemon: "glusterfsd"}
+	Notify(e)
+	select {
+	case r := <-received:
+		assert.Equal(t, e, r)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	assert.Nil(t, Delete(wh.ID.String()))
+	waitWorker(t, wh.ID.String(), false)
+
+	// The workers are stopped with Start
+	assert.Nil(t, Add(wh))
+	waitWorker(t, wh.ID.String(), true)
+	cancel()
+	waitWorker(t, wh.ID.String(), false)
+}
+
+// TestWatchLiveness validates that the peer-online and peer-offline events
+// are only sent by the alive peer with the lowest ID
+func TestWatchLiveness(t *testing.T) {
+	defer initTestStore(t)()
+
+	received := make(chan api.Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e api.Event
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&e))
+		received <- e
+	}))
+	defer srv.Close()
+	assert.Nil(t, Add(&Webhook{ID: uuid.NewRandom(), URL: srv.URL}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Start(ctx)
+	go WatchLiveness(ctx)
+	// Let the watch start before changing the liveness keys
+	time.Sleep(time.Second)
+
+	receive := func() api.Event {
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return api.Event{}
+	}
+	alive := func(id string) {
+		_, err := store.Store.Put(context.Background(), store.GlusterPrefix+"alive/"+id, "")
+		assert.Nil(t, err)
+	}
+	down := func(id string) {
+		_, err := store.Store.Delete(context.Background(), store.GlusterPrefix+"alive/"+id)
+		assert.Nil(t, err)
+	}
+
+	// This node has a lower ID than the other node, so it sends the events
+	// of the other node
+	higher := "ffffffff-ffff-ffff-ffff-ffffffffffff"
+	alive(higher)
+	assert.Equal(t, api.Event{Type: api.EventPeerOnline, Peer: higher}, receive())
+	down(higher)
+	assert.Equal(t, api.Event{Type: api.EventPeerOffline, Peer: higher}, receive())
+
+	// While a node with a lower ID is alive, that node sends the events.
+	// Once it goes down, this node sends them again, so the next event is
+	// the offline event of the lower node.
+	lower := "00000000-0000-0000-0000-000000000000"
+	alive(lower)
+	alive(higher)
+	// Let the changes be seen while the lower node is still alive
+	time.Sleep(time.Second)
+	down(lower)
+	assert.Equal(t, api.Event{Type: api.EventPeerOffline, Peer: lower}, receive())
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gluster/glusterd2/glusterd2/store"
+	"github.com/gluster/glusterd2/pkg/api"
+	"github.com/gluster/glusterd2/pkg/errors"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pborman/uuid"
+)
+
+// StorePrefix is the prefix of the keys the webhooks are saved under
+const StorePrefix = store.GlusterPrefix + "webhooks/"
+
+// Webhook is a registered webhook, as saved in the store
+type Webhook struct {
+	ID     uuid.UUID
+	URL    string
+	Secret string
+	Events []api.EventType
+}
+
+// Wants returns true if the event should be sent to the webhook
+func (w *Webhook) Wants(e *api.Event) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Add registers the webhook
+func Add(w *Webhook) error {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.Store.Put(context.TODO(), StorePrefix+w.ID.String(), string(b))
+	return err
+}
+
+// Get returns the webhook with the given ID
+func Get(id string) (*Webhook, error) {
+	resp, err := store.Store.Get(context.TODO(), StorePrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Count == 0 {
+		return nil, errors.ErrWebhookNotFound
+	}
+
+	var w Webhook
+	if err := json.Unmarshal(resp.Kvs[0].Value, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Delete unregisters the webhook with the given ID
+func Delete(id string) error {
+	resp, err := store.Store.Delete(context.TODO(), StorePrefix+id)
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return errors.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetWebhooks returns all the registered webhooks
+func GetWebhooks() ([]*Webhook, error) {
+	resp, err := store.Store.Get(context.TODO(), StorePrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]*Webhook, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var w Webhook
+		if err := json.Unmarshal(kv.Value, &w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &w)
+	}
+	return webhooks, nil
+}
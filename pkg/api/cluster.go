@@ -0,0 +1,17 @@
+package api
+
+// ClusterOpVersionResp is the response sent for a cluster op-version get
+// request
+type ClusterOpVersionResp struct {
+	OpVersion int `json:"op-version"`
+	// MinOpVersion and MaxOpVersion are the lowest and the highest
+	// op-versions supported by all the peers. The cluster op-version can
+	// be raised up to MaxOpVersion.
+	MinOpVersion int `json:"min-op-version"`
+	MaxOpVersion int `json:"max-op-version"`
+}
+
+// ClusterOpVersionReq represents a request to raise the cluster op-version
+type ClusterOpVersionReq struct {
+	OpVersion int `json:"op-version"`
+}
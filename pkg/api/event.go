@@ -0,0 +1,52 @@
+package api
+
+// EventType is the type of an Event
+type EventType string
+
+// These are the types of the events streamed by the /events endpoint
+const (
+	EventVolumeCreated       EventType = "volume-created"
+	EventVolumeDeleted       EventType = "volume-deleted"
+	EventVolumeStarted       EventType = "volume-started"
+	EventVolumeStopped       EventType = "volume-stopped"
+	EventVolumeOptionChanged EventType = "volume-option-changed"
+	EventPeerAdded           EventType = "peer-added"
+	EventPeerRemoved         EventType = "peer-removed"
+	EventBrickOnline         EventType = "brick-online"
+	EventBrickOffline        EventType = "brick-offline"
+)
+
+// These are the types of the events only sent to webhooks
+const (
+	EventTransactionSucceeded EventType = "transaction-succeeded"
+	EventTransactionFailed    EventType = "transaction-failed"
+	EventDaemonExited         EventType = "daemon-exited"
+	EventPeerOnline           EventType = "peer-online"
+	EventPeerOffline          EventType = "peer-offline"
+)
+
+// Event is a change of the state of the cluster. Events are streamed by the
+// /events endpoint as server-sent events, with the revision as the event ID.
+type Event struct {
+	// Revision is the store revision at which the change was made. The
+	// stream can be resumed after an event by requesting the events after
+	// its revision.
+	Revision int64     `json:"revision"`
+	Type     EventType `json:"type"`
+	Volume   string    `json:"volume,omitempty"`
+	// Peer is the ID of the peer added or removed, or of the peer the
+	// brick is on
+	Peer  string `json:"peer,omitempty"`
+	Brick string `json:"brick,omitempty"`
+	// Options are the new values of the changed volume options. Options
+	// which were reset have empty values.
+	Options map[string]string `json:"options,omitempty"`
+	// Transaction is the ID of the transaction which succeeded or failed,
+	// and Resources are the keys it locked
+	Transaction string   `json:"transaction,omitempty"`
+	Resources   []string `json:"resources,omitempty"`
+	// Daemon is the name of the daemon which exited, like glusterfsd
+	Daemon string `json:"daemon,omitempty"`
+	// Error is the error of the failed transaction or of the exited daemon
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// HealthStatus is the status of a node, as seen by the store
+type HealthStatus string
+
+// These are the statuses of nodes
+const (
+	// HealthOnline nodes are alive and publish their health regularly
+	HealthOnline HealthStatus = "online"
+	// HealthUnresponsive nodes are still alive as seen by the store, but
+	// have stopped publishing their health
+	HealthUnresponsive HealthStatus = "unresponsive"
+	// HealthOffline nodes are not alive as seen by the store
+	HealthOffline HealthStatus = "offline"
+)
+
+// NodeHealth is the health record of a node. Except for the status, it is the
+// last record published by the node.
+type NodeHealth struct {
+	ID              uuid.UUID     `json:"id"`
+	Status          HealthStatus  `json:"status"`
+	LastHeartbeat   time.Time     `json:"last-heartbeat"`
+	Uptime          time.Duration `json:"uptime"`
+	GlusterdVersion string        `json:"glusterd-version"`
+	OpVersion       int           `json:"op-version"`
+	// Load is the 1 minute load average of the node
+	Load          float64 `json:"load"`
+	RunningBricks int     `json:"running-bricks"`
+	// StoreLatency is the time taken by the node to publish its previous
+	// health record into the store
+	StoreLatency time.Duration `json:"store-latency"`
+}
@@ -0,0 +1,69 @@
+package api
+
+// NominationPolicy decides how a peer is nominated as a server of the embedded
+// store
+type NominationPolicy string
+
+// These are the nomination policies of peers
+const (
+	// NominationAuto peers are nominated as required to keep the ideal
+	// number of store servers
+	NominationAuto NominationPolicy = "auto"
+	// NominationPinned peers are always store servers, even if the ideal
+	// size is exceeded
+	NominationPinned NominationPolicy = "pinned"
+	// NominationExcluded peers are never store servers
+	NominationExcluded NominationPolicy = "excluded"
+)
+
+// StoreMember describes a peer taking part in the embedded store, as a
+// volunteer to be a store server, as a nominated server or as a member of the
+// etcd cluster
+type StoreMember struct {
+	// ID is the ID of the peer
+	ID         string           `json:"id"`
+	Volunteer  bool             `json:"volunteer"`
+	Nominee    bool             `json:"nominee"`
+	Leader     bool             `json:"leader"`
+	Nomination NominationPolicy `json:"nomination"`
+	// Zone and Rack are the failure domain of the peer. The store servers
+	// are spread across zones, and then across racks.
+	Zone       string   `json:"zone,omitempty"`
+	Rack       string   `json:"rack,omitempty"`
+	PeerURLs   []string `json:"peer-urls"`
+	ClientURLs []string `json:"client-urls,omitempty"`
+	// Server is true if the peer is a member of the etcd cluster. Healthy
+	// is true if its etcd server is responding, or else Error is the
+	// reason it isn't.
+	Server  bool   `json:"server"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// StoreMembersResp is the response sent for a store members request
+type StoreMembersResp struct {
+	// IdealSize is the number of store servers the cluster tries to keep
+	IdealSize int `json:"ideal-size"`
+	// Leader is the ID of the peer which nominates the store servers
+	Leader  string        `json:"leader"`
+	Members []StoreMember `json:"members"`
+}
+
+// StoreIdealSizeReq represents a request to change the number of store
+// servers
+type StoreIdealSizeReq struct {
+	IdealSize int `json:"ideal-size"`
+}
+
+// StoreMemberReq represents a request to change the nomination policy of a
+// peer
+type StoreMemberReq struct {
+	Nomination NominationPolicy `json:"nomination"`
+}
+
+// StoreSnapshotResp is the response sent for a store snapshot request
+type StoreSnapshotResp struct {
+	// Path is where the snapshot was saved on the peer serving the request
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
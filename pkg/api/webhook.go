@@ -0,0 +1,25 @@
+package api
+
+import "github.com/pborman/uuid"
+
+// WebhookAddReq represents a request to register a webhook
+type WebhookAddReq struct {
+	URL string `json:"url"`
+	// Secret, if set, is used to sign the events sent to the webhook. The
+	// signature is sent in the X-Gluster-Signature header, as
+	// "sha256=<hex encoded HMAC-SHA256 of the body>".
+	Secret string `json:"secret,omitempty"`
+	// Events are the types of the events sent to the webhook. All events
+	// are sent if empty.
+	Events []EventType `json:"events,omitempty"`
+}
+
+// Webhook represents a registered webhook
+type Webhook struct {
+	ID     uuid.UUID   `json:"id"`
+	URL    string      `json:"url"`
+	Events []EventType `json:"events,omitempty"`
+}
+
+// WebhookListResp is the response sent for a webhook list request
+type WebhookListResp []Webhook
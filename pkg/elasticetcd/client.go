@@ -22,13 +22,49 @@ func (ee *ElasticEtcd) Session() *concurrency.Session {
 	return ee.session
 }
 
+// RenewSession replaces the etcd session of ElasticEtcd, whose lease has
+// expired. The volunteer and election keys were removed with the old lease, so
+// ElasticEtcd volunteers itself and campaigns to become the leader again with
+// the new session. If it was the leader, it stops its leader functions, as
+// another server may have been elected in the meantime.
+func (ee *ElasticEtcd) RenewSession() (*concurrency.Session, error) {
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	if ee.stopping {
+		return nil, errors.New("elasticetcd is stopping")
+	}
+
+	session, err := concurrency.NewSession(ee.cli)
+	if err != nil {
+		return nil, err
+	}
+	// The old lease is gone, so there is nothing to revoke
+	ee.session.Orphan()
+	ee.session = session
+
+	ee.stopLeader(ee.stopleading)
+
+	if err := ee.putVolunteer(); err != nil {
+		return nil, err
+	}
+	ee.startCampaign(session)
+
+	return session, nil
+}
+
 // startClient starts the etcd client and connects the ElasticEtcd instance to the elastic cluster.
 func (ee *ElasticEtcd) startClient() error {
 	if ee.cli != nil {
 		return errors.New("client already exists")
 	}
 
-	cli, err := clientv3.New(ee.newClientConfig())
+	conf, err := ee.newClientConfig()
+	if err != nil {
+		return err
+	}
+
+	cli, err := clientv3.New(conf)
 	if err != nil {
 		return err
 	}
@@ -72,19 +108,31 @@ func (ee *ElasticEtcd) stopClient() error {
 }
 
 // newClientConfig returns a new etcd clientv3.Config from the ElasticEtcd config
-func (ee *ElasticEtcd) newClientConfig() clientv3.Config {
+func (ee *ElasticEtcd) newClientConfig() (clientv3.Config, error) {
+	tlsConf, err := ee.conf.ClientTLS.ClientConfig()
+	if err != nil {
+		return clientv3.Config{}, err
+	}
+
 	return clientv3.Config{
-		Endpoints:        ee.conf.Endpoints.StringSlice(),
+		Endpoints:        ee.conf.ClientTLS.SecureURLs(ee.conf.Endpoints).StringSlice(),
 		AutoSyncInterval: 30 * time.Second, // Update list of endpoints ever 30s.
 		DialTimeout:      5 * time.Second,
 		RejectOldCluster: true,
-	}
+		TLS:              tlsConf,
+	}, nil
 }
 
 // watch watches for changes the given key and runs the handler when changes happen.
 // watch also waits on the stopwatching channel and stops watching when notified.
 // All watchers in ElasticEtcd must to use this instead of using starting their own etcd watchers.
 func (ee *ElasticEtcd) watch(key string, handler func(clientv3.WatchResponse), watchopts ...clientv3.OpOption) {
+	ee.watchUntil(nil, key, handler, watchopts...)
+}
+
+// watchUntil is like watch, but also stops watching when the given stop
+// channel is closed
+func (ee *ElasticEtcd) watchUntil(stop <-chan struct{}, key string, handler func(clientv3.WatchResponse), watchopts ...clientv3.OpOption) {
 	ee.watchers.Add(1)
 	go func() {
 		defer ee.watchers.Done()
@@ -99,6 +147,8 @@ func (ee *ElasticEtcd) watch(key string, handler func(clientv3.WatchResponse), w
 				handler(resp)
 			case <-ee.stopwatching:
 				return
+			case <-stop:
+				return
 			}
 		}
 	}()
@@ -3,6 +3,7 @@ package elasticetcd
 import (
 	"net"
 	"path"
+	"time"
 
 	"github.com/coreos/etcd/pkg/types"
 )
@@ -15,6 +16,9 @@ const (
 	DefaultName      = "elasticetcd"
 	DefaultIdealSize = 3
 	DefaultDir       = "."
+
+	DefaultCompactionInterval = time.Hour
+	DefaultDefragInterval     = 24 * time.Hour
 )
 
 var (
@@ -69,6 +73,17 @@ type Config struct {
 	Endpoints, CURLs, PURLs types.URLs
 	IdealSize               int
 	DisableLogging          bool
+	// Domain is the failure domain of the server, used to spread the
+	// nominations
+	Domain FailureDomain
+	// CompactionInterval is the interval at which the leader compacts the
+	// etcd history, keeping the history of the last interval.
+	// DefragInterval is the interval at which the leader defragments the
+	// etcd servers. A zero interval disables the task.
+	CompactionInterval, DefragInterval time.Duration
+	// ClientTLS and PeerTLS are the TLS options of the client and the peer
+	// connections. If set, the http URLs are used with https instead.
+	ClientTLS, PeerTLS TLSInfo
 }
 
 // NewConfig returns an ElasticEtcd config with defaults filled
@@ -81,6 +96,9 @@ func NewConfig() *Config {
 		CURLs:     defaultCURLs,
 		PURLs:     defaultPURLs,
 		IdealSize: DefaultIdealSize,
+
+		CompactionInterval: DefaultCompactionInterval,
+		DefragInterval:     DefaultDefragInterval,
 	}
 }
 
@@ -6,13 +6,24 @@
 //  	- If no endpoints are given, assume you are the first instance up and start your embedded etcd server
 //	- Connect to the given endpoints (or your own etcd endpoints if you were the first one up)
 //	- Volunteer to be a server, and wait to be nominated as a server
-//		- If you are nominated, start your embedded etcd server and join the existing cluster
+//		- If you are nominated, wait to be added as a cluster member, then start your embedded etcd server and join the existing cluster
 //		- If your nomination is removed, stop your embedded etcd server
 //	- Begin a campaign to become the leader of the elastic cluster
 // 		- When elected as the leader, make nominations from the volunteer list, to keep the right number of servers.
 // 		- Watch for changes to the volunteer list, online servers and the ideal size, and make/remove nominations as required.
+// 		- Watch the election key, and check the leadership periodically. If the leadership is lost, stop the leader functions and campaign again.
+// 		- Compact the etcd history and defragment the etcd servers periodically, to keep the etcd backends within their quota.
 //
-// Right now the server nominations are selected in a round-robin fashion, using the list of volunteers sorted by name.
+// Volunteers publish their failure domain, a zone and a rack, along with their volunteering.
+// The server nominations are spread across the zones first and then the racks, choosing the volunteers in the least crowded failure domain.
+// Ties are broken using the list of volunteers sorted by name.
+//
+// A nomination records the peer URLs of the nominee. Once the leader has added the nominee as a cluster member, it acknowledges the member add in the nomination, with the member ID and the initial cluster to start the server with.
+// Nominations left unacknowledged by a previous leader are completed by the next leader.
+// When a failure domain loses its server, and a volunteer in a less crowded failure domain becomes available, the nomination is moved to it.
+//
+// The client and peer connections can use TLS, with given certificates or with generated self-signed ones.
+// When TLS is used, the http URLs are used with https instead.
 //
 // TODO: Figure out and implement recovery steps, for recovering from a complete cluster shutdown
 //
@@ -0,0 +1,151 @@
+package elasticetcd
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// Volunteers publish their failure domain under this prefix, along with the
+// volunteer key
+const domainPrefix = eePrefix + "/domains/"
+
+// FailureDomain is the location of a server. Servers in the same zone, or in
+// the same rack of a zone, are likely to fail together, so the leader spreads
+// the nominations across zones first, and then across racks.
+type FailureDomain struct {
+	Zone string `json:"zone,omitempty"`
+	Rack string `json:"rack,omitempty"`
+}
+
+// failureDomains returns the failure domains published by the volunteers.
+// Volunteers which haven't published one are in the empty failure domain.
+func (ee *ElasticEtcd) failureDomains(ctx context.Context) (map[string]FailureDomain, error) {
+	resp, err := ee.cli.Get(ctx, domainPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make(map[string]FailureDomain)
+	for _, kv := range resp.Kvs {
+		var d FailureDomain
+		if err := json.Unmarshal(kv.Value, &d); err != nil {
+			ee.log.WithError(err).WithField("key", string(kv.Key)).Warn("could not parse failure domain, ignoring")
+			continue
+		}
+		domains[strings.TrimPrefix(string(kv.Key), domainPrefix)] = d
+	}
+	return domains, nil
+}
+
+// crowding is how many nominees there are in a zone and in a rack of the zone.
+// Less crowded failure domains are preferred for nominations.
+type crowding struct {
+	zone, rack int
+}
+
+func (c crowding) less(o crowding) bool {
+	if c.zone != o.zone {
+		return c.zone < o.zone
+	}
+	return c.rack < o.rack
+}
+
+// nominations tracks the nominees and the volunteers available to be
+// nominated, and how the nominees are spread across the failure domains, while
+// the leader does nominations
+type nominations struct {
+	// nominees and available are sorted by name, so that nominations are
+	// done in the same order as the volunteers list when the failure domains
+	// don't decide
+	nominees  []string
+	available []string
+	// fixed nominees can't have their nominations removed
+	fixed map[string]bool
+
+	domains map[string]FailureDomain
+	zones   map[string]int
+	racks   map[FailureDomain]int
+}
+
+func newNominations(nominees, available []string, fixed map[string]bool, domains map[string]FailureDomain) *nominations {
+	n := &nominations{
+		available: append([]string(nil), available...),
+		fixed:     fixed,
+		domains:   domains,
+		zones:     make(map[string]int),
+		racks:     make(map[FailureDomain]int),
+	}
+	sort.Strings(n.available)
+	for _, h := range nominees {
+		n.added(h)
+	}
+	return n
+}
+
+// added records the nomination of the given host
+func (n *nominations) added(h string) {
+	n.nominees = append(n.nominees, h)
+	sort.Strings(n.nominees)
+	n.available = diffStringSlices(n.available, []string{h})
+
+	d := n.domains[h]
+	n.zones[d.Zone]++
+	n.racks[d]++
+}
+
+// removed records the removal of the nomination of the given host
+func (n *nominations) removed(h string) {
+	n.nominees = diffStringSlices(n.nominees, []string{h})
+
+	d := n.domains[h]
+	n.zones[d.Zone]--
+	n.racks[d]--
+}
+
+// skip stops considering the given host for nomination
+func (n *nominations) skip(h string) {
+	n.available = diffStringSlices(n.available, []string{h})
+}
+
+// bestCandidate returns the available host in the least crowded failure
+// domain, and the crowding of its domain. An empty host is returned if none
+// are available.
+func (n *nominations) bestCandidate() (string, crowding) {
+	var (
+		best string
+		min  crowding
+	)
+	for _, h := range n.available {
+		d := n.domains[h]
+		c := crowding{n.zones[d.Zone], n.racks[d]}
+		if best == "" || c.less(min) {
+			best, min = h, c
+		}
+	}
+	return best, min
+}
+
+// worstNominee returns the nominee, whose nomination can be removed, in the
+// most crowded failure domain, and the crowding of its domain without it. An
+// empty host is returned if no nomination can be removed.
+func (n *nominations) worstNominee() (string, crowding) {
+	var (
+		worst string
+		max   crowding
+	)
+	for _, h := range n.nominees {
+		if n.fixed[h] {
+			continue
+		}
+		d := n.domains[h]
+		c := crowding{n.zones[d.Zone] - 1, n.racks[d] - 1}
+		if worst == "" || max.less(c) {
+			worst, max = h, c
+		}
+	}
+	return worst, max
+}
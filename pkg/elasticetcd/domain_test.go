@@ -0,0 +1,198 @@
+package elasticetcd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+)
+
+var testDomains = map[string]FailureDomain{
+	"leader": {"z1", "r1"},
+	"a":      {"z1", "r1"},
+	"b":      {"z1", "r2"},
+	"c":      {"z2", "r1"},
+	"d":      {"z3", "r1"},
+}
+
+func TestBestCandidate(t *testing.T) {
+	n := newNominations([]string{"leader"}, []string{"d", "c", "b", "a"}, nil, testDomains)
+
+	// Zones without nominees come first, in the order of names
+	expected := []string{"c", "d", "b", "a"}
+	for _, e := range expected {
+		h, _ := n.bestCandidate()
+		if h != e {
+			t.Fatalf("bestCandidate(): expected %s, got %s", e, h)
+		}
+		n.added(h)
+	}
+
+	if h, _ := n.bestCandidate(); h != "" {
+		t.Errorf("bestCandidate(): expected no candidate, got %s", h)
+	}
+}
+
+func TestWorstNominee(t *testing.T) {
+	fixed := map[string]bool{"leader": true}
+	n := newNominations([]string{"leader", "a", "b", "c", "d"}, nil, fixed, testDomains)
+
+	// The most crowded rack of the most crowded zone goes first, and fixed
+	// nominees are never removed
+	expected := []string{"a", "b", "c", "d", ""}
+	for _, e := range expected {
+		h, _ := n.worstNominee()
+		if h != e {
+			t.Fatalf("worstNominee(): expected %s, got %s", e, h)
+		}
+		if h != "" {
+			n.removed(h)
+		}
+	}
+}
+
+// fakeCluster is a clientv3.Cluster which only records the members added and
+// removed. The members are named after the host of their peer URL.
+type fakeCluster struct {
+	clientv3.Cluster
+
+	members map[uint64]*etcdserverpb.Member
+	lastID  uint64
+}
+
+func (c *fakeCluster) MemberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	resp := &clientv3.MemberListResponse{}
+	for _, m := range c.members {
+		resp.Members = append(resp.Members, m)
+	}
+	return resp, nil
+}
+
+func (c *fakeCluster) MemberAdd(ctx context.Context, peerAddrs []string) (*clientv3.MemberAddResponse, error) {
+	u, err := url.Parse(peerAddrs[0])
+	if err != nil {
+		return nil, err
+	}
+	c.lastID++
+	m := &etcdserverpb.Member{ID: c.lastID, Name: u.Hostname(), PeerURLs: peerAddrs}
+	c.members[m.ID] = m
+	return &clientv3.MemberAddResponse{Member: m}, nil
+}
+
+func (c *fakeCluster) MemberRemove(ctx context.Context, id uint64) (*clientv3.MemberRemoveResponse, error) {
+	delete(c.members, id)
+	return &clientv3.MemberRemoveResponse{}, nil
+}
+
+// newTestLeader returns an ElasticEtcd running its own etcd server, which
+// can do nominations. Members are not really added to the etcd cluster.
+func newTestLeader(t *testing.T, idealSize int) (*ElasticEtcd, func()) {
+	dir, err := ioutil.TempDir("", "elasticetcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Name = "leader"
+	conf.Dir = dir
+	conf.LogDir = path.Join(dir, "log")
+	conf.CURLs = freeURL(t)
+	conf.PURLs = freeURL(t)
+	conf.IdealSize = idealSize
+	conf.DisableLogging = true
+
+	ee := &ElasticEtcd{conf: conf, stopwatching: make(chan struct{})}
+	ee.initLogging()
+	if err := ee.startServer(""); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	ee.conf.Endpoints = ee.server.srv.Config().ACUrls
+	if err := ee.startClient(); err != nil {
+		ee.Stop()
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	ee.cli.Cluster = &fakeCluster{members: make(map[uint64]*etcdserverpb.Member)}
+
+	// The leader is a nominee, as it runs the first server
+	ee.addToNominees(conf.Name, conf.PURLs)
+
+	return ee, func() {
+		ee.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// volunteer adds the given host, in the given failure domain, to the
+// volunteer list
+func volunteer(t *testing.T, ee *ElasticEtcd, host string, d FailureDomain) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ee.cli.Txn(ee.cli.Ctx()).Then(
+		clientv3.OpPut(domainPrefix+host, string(b)),
+		clientv3.OpPut(volunteerPrefix+host, "http://"+host+":2380"),
+	).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func unvolunteer(t *testing.T, ee *ElasticEtcd, host string) {
+	if _, err := ee.cli.Delete(ee.cli.Ctx(), volunteerPrefix+host); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkNominees(t *testing.T, ee *ElasticEtcd, expected ...string) {
+	resp, err := ee.cli.Get(ee.cli.Ctx(), nomineePrefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nominees := keysFromGetResp(resp, nomineePrefix)
+	if !compareStringSlices(expected, nominees) {
+		t.Fatalf("expected nominees %v, got %v", expected, nominees)
+	}
+}
+
+// TestDoNominationsFailureDomains validates that nominations are spread
+// across failure domains, and are moved when a failure domain loses its
+// nominee
+func TestDoNominationsFailureDomains(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd test in short mode")
+	}
+
+	ee, cleanup := newTestLeader(t, 3)
+	defer cleanup()
+
+	for _, h := range []string{"leader", "a", "b", "c", "d"} {
+		volunteer(t, ee, h, testDomains[h])
+	}
+
+	// a and b are in the zone of the leader
+	ee.doNominations()
+	checkNominees(t, ee, "c", "d", "leader")
+
+	// The only other zone left is the zone of the leader
+	unvolunteer(t, ee, "d")
+	ee.doNominations()
+	checkNominees(t, ee, "b", "c", "leader")
+
+	// b moves to the new zone
+	volunteer(t, ee, "e", FailureDomain{"z4", "r1"})
+	ee.doNominations()
+	checkNominees(t, ee, "c", "e", "leader")
+
+	// Nothing moves when the nominees are spread as much as possible
+	ee.doNominations()
+	checkNominees(t, ee, "c", "e", "leader")
+}
@@ -18,6 +18,10 @@ type ElasticEtcd struct {
 	session  *concurrency.Session
 	election *concurrency.Election
 
+	// stopleading is closed to stop the leader functions, when the
+	// leadership is lost. It is nil when not the leader.
+	stopleading chan struct{}
+
 	conf *Config // the ElasticEtcd configuration
 
 	log     *logrus.Logger
@@ -29,6 +33,9 @@ type ElasticEtcd struct {
 	watchers     sync.WaitGroup
 
 	lock sync.RWMutex
+	// nominating serializes the nominations done by the leader, which
+	// don't hold lock while waiting for the nominees
+	nominating sync.Mutex
 }
 
 // New returns an initialized and connected ElasticEtcd, ready for use
@@ -73,7 +80,7 @@ func New(conf *Config) (*ElasticEtcd, error) {
 	}
 
 	// Start campaign to become the leader
-	ee.startCampaign()
+	ee.startCampaign(ee.session)
 
 	return ee, nil
 }
@@ -0,0 +1,204 @@
+package elasticetcd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+// testCluster is a set of ElasticEtcd instances running in the same process,
+// each with its own embedded server ports and data directory
+type testCluster struct {
+	dir       string
+	instances []*ElasticEtcd
+	stopped   map[*ElasticEtcd]bool
+}
+
+// freeURL returns a URL on localhost with a port that is free
+func freeURL(t *testing.T) types.URLs {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return types.MustNewURLs([]string{"http://" + l.Addr().String()})
+}
+
+// newTestCluster starts n ElasticEtcd instances, and waits for all of them to
+// be nominated as servers. The first instance starts its own server, and the
+// others join it.
+func newTestCluster(t *testing.T, n int) *testCluster {
+	dir, err := ioutil.TempDir("", "elasticetcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testCluster{dir: dir, stopped: make(map[*ElasticEtcd]bool)}
+
+	var endpoints types.URLs
+	for i := 0; i < n; i++ {
+		conf := NewConfig()
+		conf.Name = fmt.Sprintf("ee%d", i)
+		conf.Dir = path.Join(dir, conf.Name)
+		conf.LogDir = path.Join(conf.Dir, "log")
+		conf.CURLs = freeURL(t)
+		conf.PURLs = freeURL(t)
+		conf.Endpoints = endpoints
+		conf.IdealSize = n
+		conf.DisableLogging = true
+
+		ee, err := New(conf)
+		if err != nil {
+			c.stop()
+			t.Fatalf("failed to start instance %s: %s", conf.Name, err)
+		}
+		c.instances = append(c.instances, ee)
+		if endpoints == nil {
+			endpoints = conf.CURLs
+		}
+	}
+
+	c.waitFor(t, 2*time.Minute, "all instances to be nominated", func() bool {
+		return c.members() == n
+	})
+
+	// The clients only know the endpoint of the first instance, update them
+	// so that they can keep working without it
+	for _, ee := range c.instances {
+		ee.cli.Sync(ee.cli.Ctx())
+	}
+
+	return c
+}
+
+// members returns the number of members of the etcd cluster
+func (c *testCluster) members() int {
+	for _, ee := range c.instances {
+		if c.stopped[ee] {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ee.cli.Ctx(), 5*time.Second)
+		resp, err := ee.cli.MemberList(ctx)
+		cancel()
+		if err != nil {
+			return 0
+		}
+		return len(resp.Members)
+	}
+	return 0
+}
+
+// leaders returns the running instances which believe they are the leader
+func (c *testCluster) leaders() []*ElasticEtcd {
+	var leaders []*ElasticEtcd
+	for _, ee := range c.instances {
+		if !c.stopped[ee] && ee.IsLeader() {
+			leaders = append(leaders, ee)
+		}
+	}
+	return leaders
+}
+
+// waitForLeader waits for a single running instance to be the leader, and
+// returns it
+func (c *testCluster) waitForLeader(t *testing.T) *ElasticEtcd {
+	var leader *ElasticEtcd
+	c.waitFor(t, time.Minute, "a single leader", func() bool {
+		leaders := c.leaders()
+		if len(leaders) != 1 {
+			return false
+		}
+		leader = leaders[0]
+		return true
+	})
+	return leader
+}
+
+func (c *testCluster) waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			c.stop()
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func (c *testCluster) stopInstance(ee *ElasticEtcd) {
+	if !c.stopped[ee] {
+		ee.Stop()
+		c.stopped[ee] = true
+	}
+}
+
+func (c *testCluster) stop() {
+	for _, ee := range c.instances {
+		c.stopInstance(ee)
+	}
+	os.RemoveAll(c.dir)
+}
+
+// TestLeaderHandover validates that when the leader is stopped, another
+// instance takes over as the leader and resumes the leader functions
+func TestLeaderHandover(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping elastic cluster test in short mode")
+	}
+
+	c := newTestCluster(t, 3)
+	defer c.stop()
+
+	leader := c.waitForLeader(t)
+	c.stopInstance(leader)
+
+	newLeader := c.waitForLeader(t)
+	if newLeader == leader {
+		t.Fatal("stopped instance is still the leader")
+	}
+
+	// The new leader removes the nomination of the stopped instance, as it
+	// is no longer a volunteer
+	c.waitFor(t, time.Minute, "the stopped instance to be removed from the cluster", func() bool {
+		return c.members() == 2
+	})
+}
+
+// TestLeaderStepsDown validates that the leader stops its leader functions
+// when its election key is lost, and campaigns again
+func TestLeaderStepsDown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping elastic cluster test in short mode")
+	}
+
+	c := newTestCluster(t, 1)
+	defer c.stop()
+
+	leader := c.waitForLeader(t)
+	leader.lock.RLock()
+	stopleading := leader.stopleading
+	leader.lock.RUnlock()
+
+	// Deleting the election key takes away the leadership, as happens
+	// when its lease expires
+	if _, err := leader.cli.Delete(leader.cli.Ctx(), electionKey+"/", clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopleading:
+	case <-time.After(time.Minute):
+		t.Fatal("timed out waiting for the leader to step down")
+	}
+
+	// With no other instances, it wins the election again
+	if c.waitForLeader(t) != leader {
+		t.Fatal("expected the only instance to be the leader again")
+	}
+}
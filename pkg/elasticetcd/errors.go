@@ -9,4 +9,10 @@ var (
 	ErrClientNotAvailable = errors.New("etcd client not available")
 	// ErrAddingSelfToServerList is returned when an ElasticEtcd instance fails to add itself to the nominated servers list
 	ErrAddingSelfToServerList = errors.New("failed to add self to server list")
+	// ErrInvalidIdealSize is returned when trying to set an ideal size lower than 1
+	ErrInvalidIdealSize = errors.New("ideal size must be at least 1")
+	// ErrInvalidNominationPolicy is returned when trying to set an unknown nomination policy
+	ErrInvalidNominationPolicy = errors.New("invalid nomination policy")
+	// ErrMemberAddTimeout is returned when a nominee isn't added as an etcd cluster member in time
+	ErrMemberAddTimeout = errors.New("timed out waiting to be added as etcd cluster member")
 )
@@ -30,6 +30,18 @@ func init() {
 	pflag.StringSliceVar(&endpoints, "endpoints", nil, "endpoints of existing etcd cluster")
 	pflag.StringSliceVar(&purls, "purls", nil, "etcd peer urls to listen on")
 	pflag.StringSliceVar(&curls, "curls", nil, "etcd client urls to listen on")
+	pflag.StringVar(&flags.Domain.Zone, "zone", "", "failure domain zone of this instance")
+	pflag.StringVar(&flags.Domain.Rack, "rack", "", "failure domain rack of this instance")
+	pflag.DurationVar(&flags.CompactionInterval, "compaction-interval", elasticetcd.DefaultCompactionInterval, "interval at which the etcd history is compacted")
+	pflag.DurationVar(&flags.DefragInterval, "defrag-interval", elasticetcd.DefaultDefragInterval, "interval at which the etcd servers are defragmented")
+	pflag.StringVar(&flags.ClientTLS.CertFile, "cert-file", "", "certificate for client connections")
+	pflag.StringVar(&flags.ClientTLS.KeyFile, "key-file", "", "key for client connections")
+	pflag.StringVar(&flags.ClientTLS.CAFile, "trusted-ca-file", "", "CA for client connections")
+	pflag.BoolVar(&flags.ClientTLS.AutoTLS, "auto-tls", false, "use generated certificates for client connections")
+	pflag.StringVar(&flags.PeerTLS.CertFile, "peer-cert-file", "", "certificate for peer connections")
+	pflag.StringVar(&flags.PeerTLS.KeyFile, "peer-key-file", "", "key for peer connections")
+	pflag.StringVar(&flags.PeerTLS.CAFile, "peer-trusted-ca-file", "", "CA for peer connections")
+	pflag.BoolVar(&flags.PeerTLS.AutoTLS, "peer-auto-tls", false, "use generated certificates for peer connections")
 }
 
 func parseFlags() {
@@ -41,6 +53,11 @@ func getConf() (*elasticetcd.Config, error) {
 
 	conf.Name = flags.Name
 	conf.IdealSize = flags.IdealSize
+	conf.Domain = flags.Domain
+	conf.CompactionInterval = flags.CompactionInterval
+	conf.DefragInterval = flags.DefragInterval
+	conf.ClientTLS = flags.ClientTLS
+	conf.PeerTLS = flags.PeerTLS
 
 	if flags.Dir == "" {
 		return nil, errors.New("datadir not given")
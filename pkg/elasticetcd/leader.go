@@ -3,12 +3,13 @@ package elasticetcd
 import (
 	"context"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
-	"github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"github.com/coreos/etcd/pkg/types"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -19,16 +20,28 @@ const (
 	idealSizeKey    = eePrefix + "/idealSize"
 )
 
-func (ee *ElasticEtcd) startCampaign() {
+// leadershipCheckInterval is the interval at which the leader verifies that
+// it still holds the leadership, in addition to watching its election key
+var leadershipCheckInterval = 10 * time.Second
+
+// startCampaign campaigns to become the leader with the given session, till
+// the session ends. After winning, the leadership is held till it is lost,
+// after which the leader functions are stopped and the campaign begins again.
+func (ee *ElasticEtcd) startCampaign(session *concurrency.Session) {
 	go func() {
-		election := concurrency.NewElection(ee.session, electionKey)
+		election := concurrency.NewElection(session, electionKey)
 		for {
 			// Campaign for the becoming the leader
-			// Stop campaigning if the context is canceled
-			// else ignore errors and retry campaign
+			// Stop campaigning if the context is canceled or the session
+			// has ended, else ignore errors and retry campaign
 			ee.log.Debug("campaigning to become leader")
 			err := election.Campaign(ee.cli.Ctx(), ee.conf.Name)
 			if err != nil {
+				select {
+				case <-session.Done():
+					return
+				default:
+				}
 				switch {
 				case err == context.Canceled:
 					return
@@ -38,27 +51,133 @@ func (ee *ElasticEtcd) startCampaign() {
 			}
 			ee.log.Debug("won election to become leader")
 			// Resign as leader if you cannot start the leader funtions
-			if err := ee.startLeader(); err != nil {
+			stop, err := ee.startLeader()
+			if err != nil {
 				ee.log.Debug("failed to start leader functions, resigning")
 				election.Resign(ee.cli.Ctx())
 				continue
 			}
-			// You are the leader till. So stop campaigning
-			// XXX: Do we need to check for leadership periodically, or are we the leader till we die?
-			// XXX: What happens during cluster splits? Etcd should handle that, but need to verify.
-			return
+
+			ee.holdLeadership(session, election)
+
+			ee.lock.Lock()
+			ee.stopLeader(stop)
+			stopping := ee.stopping
+			ee.lock.Unlock()
+
+			select {
+			case <-session.Done():
+				return
+			default:
+			}
+			if stopping {
+				return
+			}
+			// The election key may still exist if the leadership was
+			// lost to another key, so resign before campaigning again
+			election.Resign(ee.cli.Ctx())
 		}
 	}()
 }
 
-func (ee *ElasticEtcd) startLeader() error {
-	ee.watchVolunteers()
-	ee.watchIdealSize()
+// holdLeadership returns when the leadership won with the given election is
+// lost. This happens when the election key is deleted, for example when its
+// lease expires during a network partition, or when another key is found to
+// be the leader, or when ElasticEtcd is stopped.
+func (ee *ElasticEtcd) holdLeadership(session *concurrency.Session, election *concurrency.Election) {
+	ctx, cancel := context.WithCancel(ee.cli.Ctx())
+	defer cancel()
+
+	key := election.Key()
+	wch := ee.cli.Watch(ctx, key, clientv3.WithRev(election.Rev()+1))
+
+	ticker := time.NewTicker(leadershipCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case resp, ok := <-wch:
+			if !ok || resp.Canceled {
+				ee.log.Debug("lost watch on election key, stepping down as leader")
+				return
+			}
+			for _, ev := range resp.Events {
+				if ev.Type == clientv3.EventTypeDelete {
+					ee.log.Debug("election key deleted, stepping down as leader")
+					return
+				}
+			}
+		case <-ticker.C:
+			resp, err := election.Leader(ctx)
+			if err != nil {
+				// The store may be unavailable for a while, the watch
+				// or the session will tell if the leadership is lost
+				ee.log.WithError(err).Debug("could not verify leadership")
+				continue
+			}
+			if len(resp.Kvs) == 0 || string(resp.Kvs[0].Key) != key {
+				ee.log.Debug("another server is the leader, stepping down as leader")
+				return
+			}
+		case <-session.Done():
+			ee.log.Debug("session ended, stepping down as leader")
+			return
+		case <-ee.stopwatching:
+			return
+		}
+	}
+}
 
-	return nil
+// startLeader starts the leader functions. The returned channel must be
+// passed to stopLeader to stop them.
+func (ee *ElasticEtcd) startLeader() (chan struct{}, error) {
+	ee.lock.Lock()
+	ee.stopleading = make(chan struct{})
+	stop := ee.stopleading
+	ee.lock.Unlock()
+
+	// The ideal size may have been changed while another server was the
+	// leader
+	if size, err := ee.IdealSize(ee.cli.Ctx()); err != nil {
+		ee.log.WithError(err).Warn("could not get idealsize, using the current value")
+	} else {
+		ee.lock.Lock()
+		ee.conf.IdealSize = size
+		ee.lock.Unlock()
+	}
+
+	ee.watchVolunteers(stop)
+	ee.watchIdealSize(stop)
+	ee.watchNominationPolicies(stop)
+	ee.startMaintenance(stop)
+
+	// Nominate servers for the current volunteers, policies and ideal size
+	go ee.doNominations()
+
+	return stop, nil
 }
 
-func (ee *ElasticEtcd) watchVolunteers() {
+// stopLeader stops the leader functions started with the given channel, if
+// they haven't been stopped already.
+// Ensure this is only called with ee.lock held.
+func (ee *ElasticEtcd) stopLeader(stop chan struct{}) {
+	if stop == nil || ee.stopleading != stop {
+		return
+	}
+	ee.log.Debug("stopping leader functions")
+	close(stop)
+	ee.stopleading = nil
+}
+
+// IsLeader returns true if this ElasticEtcd is the leader of the elastic
+// cluster
+func (ee *ElasticEtcd) IsLeader() bool {
+	ee.lock.RLock()
+	defer ee.lock.RUnlock()
+	return ee.stopleading != nil
+}
+
+func (ee *ElasticEtcd) watchVolunteers(stop <-chan struct{}) {
 	ee.log.Debug("watching for changes to volunteers list")
 
 	f := func(_ clientv3.WatchResponse) {
@@ -66,10 +185,10 @@ func (ee *ElasticEtcd) watchVolunteers() {
 		ee.doNominations()
 	}
 
-	ee.watch(volunteerPrefix, f, clientv3.WithPrefix())
+	ee.watchUntil(stop, volunteerPrefix, f, clientv3.WithPrefix())
 }
 
-func (ee *ElasticEtcd) watchIdealSize() {
+func (ee *ElasticEtcd) watchIdealSize(stop <-chan struct{}) {
 	ee.log.Debug("watching for changes to ideal cluster size")
 
 	f := func(resp clientv3.WatchResponse) {
@@ -79,22 +198,45 @@ func (ee *ElasticEtcd) watchIdealSize() {
 				ee.log.WithError(err).Error("could not parse idealsize value, ignoring update")
 				continue
 			}
-			if i != ee.conf.IdealSize {
+			ee.lock.Lock()
+			changed := i != ee.conf.IdealSize
+			ee.conf.IdealSize = i
+			ee.lock.Unlock()
+
+			if changed {
 				ee.log.WithField("idealsize", i).Debug("idealsize changed, doing nominations again")
-				ee.conf.IdealSize = i
 				ee.doNominations()
 			}
 		}
 	}
-	ee.watch(idealSizeKey, f)
+	ee.watchUntil(stop, idealSizeKey, f)
+}
+
+func (ee *ElasticEtcd) watchNominationPolicies(stop <-chan struct{}) {
+	ee.log.Debug("watching for changes to nomination policies")
+
+	f := func(_ clientv3.WatchResponse) {
+		ee.log.Debug("nomination policies changed, doing nominations again")
+		ee.doNominations()
+	}
+
+	ee.watchUntil(stop, pinnedPrefix, f, clientv3.WithPrefix())
+	ee.watchUntil(stop, excludedPrefix, f, clientv3.WithPrefix())
 }
 
 func (ee *ElasticEtcd) doNominations() {
-	ee.lock.Lock()
-	defer ee.lock.Unlock()
+	// Nominations may wait for nominees to start their servers, so they are
+	// serialized with their own lock, and ee.lock is only held to read the
+	// configuration
+	ee.nominating.Lock()
+	defer ee.nominating.Unlock()
+
+	ee.lock.RLock()
+	stopping, idealSize := ee.stopping, ee.conf.IdealSize
+	ee.lock.RUnlock()
 
 	// We shouldn't ever hit this, but better be safe
-	if ee.stopping {
+	if stopping {
 		return
 	}
 
@@ -119,10 +261,31 @@ func (ee *ElasticEtcd) doNominations() {
 	ee.log.WithField("nominees", nominees).Debug("current nominees")
 	ee.log.WithField("volunteers", volunteers).Debug("current volunteers")
 
+	pinned, excluded, err := ee.nominationPolicies(ee.cli.Ctx())
+	if err != nil {
+		ee.log.WithError(err).Error("could not get nomination policies")
+		return
+	}
+	ee.log.WithFields(logrus.Fields{
+		"pinned":   pinned,
+		"excluded": excluded,
+	}).Debug("current nomination policies")
+
 	// Check if anyone unvolunteered and remove them from the volunteer list
 	unvolunteered := diffStringSlices(nominees, volunteers)
 	ee.log.WithField("unvolunteered", unvolunteered).Debug("unvolunteered servers")
-	for _, h := range unvolunteered {
+	removals := unvolunteered
+
+	// Excluded servers lose their nominations as well
+	for _, h := range intersectStringSlices(nominees, excluded) {
+		if h == ee.conf.Name {
+			ee.log.Warn("cannot remove own nomination, ignoring exclusion of self")
+			continue
+		}
+		removals = append(removals, h)
+	}
+
+	for _, h := range removals {
 		if err := ee.removeNomination(h); err != nil {
 			// Just log failure and continue
 			ee.log.WithError(err).WithField("host", h).Warn("could not remove nomination")
@@ -130,10 +293,26 @@ func (ee *ElasticEtcd) doNominations() {
 	}
 
 	// Update the nominee list after the nominee removals
-	nominees = diffStringSlices(nominees, unvolunteered)
-	nomineeCount := len(nominees)
+	nominees = diffStringSlices(nominees, removals)
 	ee.log.WithField("nominees", nominees).Debug("updated nominees list")
 
+	// Complete the nominations whose member add wasn't acknowledged, for
+	// example because the previous leader died while nominating
+	for _, kv := range nomineesResp.Kvs {
+		h := strings.TrimPrefix(string(kv.Key), nomineePrefix)
+		if h == ee.conf.Name || containsString(removals, h) {
+			continue
+		}
+		n, err := parseNomination(kv.Value)
+		if err != nil || n.MemberID != 0 {
+			continue
+		}
+		ee.log.WithField("host", h).Debug("completing unacknowledged nomination")
+		if err := ee.completeNomination(h, n); err != nil {
+			ee.log.WithError(err).WithField("host", h).Warn("could not complete nomination")
+		}
+	}
+
 	// Prepare a map of volunteers names and their published CURLs
 	volunteersMap, err := urlsMapFromGetResp(volunteersResp, volunteerPrefix)
 	if err != nil {
@@ -141,80 +320,135 @@ func (ee *ElasticEtcd) doNominations() {
 		return
 	}
 
+	domains, err := ee.failureDomains(ee.cli.Ctx())
+	if err != nil {
+		ee.log.WithError(err).Error("could not get failure domains")
+		return
+	}
+
+	// You cannot remove your own nomination, or those of pinned servers
+	fixed := map[string]bool{ee.conf.Name: true}
+	for _, h := range pinned {
+		fixed[h] = true
+	}
+
+	// Filter out already nominated and excluded servers
+	available := diffStringSlices(diffStringSlices(volunteers, nominees), excluded)
+	n := newNominations(nominees, available, fixed, domains)
+
+	// Pinned servers are always nominated, even if idealSize is exceeded
+	for _, h := range intersectStringSlices(n.available, pinned) {
+		if err := ee.nominate(h, volunteersMap[h]); err != nil {
+			ee.log.WithError(err).WithField("host", h).Error("failed to nominate pinned host")
+			n.skip(h)
+			continue
+		}
+		ee.log.WithField("host", h).Debug("nominated pinned host")
+		n.added(h)
+	}
+
 	switch {
 	// If idealSize is not met, nominate more servers till the size is met
-	case nomineeCount < ee.conf.IdealSize:
+	case len(n.nominees) < idealSize:
 		// You cannot do nominations if all volunteers have been nominated
-		if compareStringSlices(nominees, volunteers) {
+		if len(n.available) == 0 {
 			ee.log.Debug("all available volunteers have been nominated")
-			return
+			break
 		}
 
-		// Filter out already nominated servers
-		available := diffStringSlices(volunteers, nominees)
-
-		// Keep nominating in a round-robin fashion till the required nominations are done
-		for _, h := range available {
-			err := ee.nominate(h, volunteersMap[h])
-			if err != nil {
+		// Keep nominating servers in the least crowded failure domains till
+		// the required nominations are done
+		for len(n.nominees) < idealSize {
+			h, _ := n.bestCandidate()
+			if h == "" {
+				break
+			}
+			if err := ee.nominate(h, volunteersMap[h]); err != nil {
 				ee.log.WithError(err).WithField("host", h).Error("failed to nominate host")
+				n.skip(h)
 				continue
 			}
-			ee.log.WithField("host", h).Debug("nominated new host")
-			nomineeCount++
-			if nomineeCount == ee.conf.IdealSize {
-				break
-			}
+			ee.log.WithFields(logrus.Fields{
+				"host":   h,
+				"domain": domains[h],
+			}).Debug("nominated new host")
+			n.added(h)
 		}
 
 		// If idealSize is exceeded, remove server nominations till idealSize is reached
-	case nomineeCount > ee.conf.IdealSize:
-		// Remove nominations in a round-robin fashion till the required nominations are removed
-		for _, h := range nominees {
-			if h == ee.conf.Name {
-				// skip yourself
-				continue
+	case len(n.nominees) > idealSize:
+		// Keep removing nominations in the most crowded failure domains
+		// till the required nominations are removed
+		for len(n.nominees) > idealSize {
+			h, _ := n.worstNominee()
+			if h == "" {
+				break
 			}
 			if err := ee.removeNomination(h); err != nil {
 				ee.log.WithError(err).WithField("host", h).Warn("could not remove nomination for host")
+				n.fixed[h] = true
 				continue
 			}
-			nomineeCount--
-			if nomineeCount == ee.conf.IdealSize {
-				break
-			}
+			n.removed(h)
 		}
 	}
 
-	ee.log.WithField("nomineecount", nomineeCount).Debug("finished doing nominations")
+	// Move nominations from crowded failure domains to less crowded ones,
+	// for example when a domain lost its nominee and a replacement could only
+	// be found in a domain that already had one. The new host is nominated
+	// before the old nomination is removed, to not reduce the cluster size.
+	for {
+		h, to := n.bestCandidate()
+		old, from := n.worstNominee()
+		if h == "" || old == "" || !to.less(from) {
+			break
+		}
+
+		if err := ee.nominate(h, volunteersMap[h]); err != nil {
+			ee.log.WithError(err).WithField("host", h).Error("failed to nominate host")
+			n.skip(h)
+			continue
+		}
+		n.added(h)
+
+		if err := ee.removeNomination(old); err != nil {
+			ee.log.WithError(err).WithField("host", old).Warn("could not remove nomination for host")
+			n.fixed[old] = true
+			continue
+		}
+		n.removed(old)
+		ee.log.WithFields(logrus.Fields{
+			"from":       old,
+			"fromdomain": domains[old],
+			"to":         h,
+			"todomain":   domains[h],
+		}).Debug("moved nomination to a less crowded failure domain")
+	}
+
+	ee.log.WithField("nomineecount", len(n.nominees)).Debug("finished doing nominations")
 }
 
 func (ee *ElasticEtcd) nominate(host string, urls types.URLs) error {
 	logger := ee.log.WithField("host", host)
 	logger.Debug("nominating host")
 
-	// Add the new host to the nominees list.
-	// Wait a little for the nominee to pick up the nomination.
-	// Then add the new host as an etcd member.
+	// Add the new host to the nominees list first, then add the new host as
+	// an etcd member, and acknowledge the member add in the nomination.
 	// If we add the new host as a member first, the embedded etcd will begin
 	// trying to connect to the newly added member and which causes new requests
 	// to be blocked, for example the PUT request to add the new nominee.
-	// If we don't wait for a little while before adding the nominee as a member,
-	// the nominee will not be able to read the nominee list it requires to start
-	// its server.
-	// This is only required for the first nominee added. When more than one
-	// server is present the etcd cluster will serve requests when a member is
-	// added.
-	// TODO: Try to figure a way to avoid the sleeping
-
-	ee.addToNominees(host, urls)
-
-	time.Sleep(2 * time.Second)
+	// The nominee waits for the acknowledgement, or to see itself in the
+	// member list, before starting its server.
+	if err := ee.addToNominees(host, urls); err != nil {
+		return err
+	}
 
-	_, err := ee.cli.MemberAdd(ee.cli.Ctx(), urls.StringSlice())
-	if err != nil {
-		logger.WithError(err).Error("failed to add host as etcd cluster member")
-		err := ee.removeFromNominees(host)
+	n := &nomination{PURLs: urls.String()}
+	if err := ee.completeNomination(host, n); err != nil {
+		if n.MemberID == 0 {
+			// The host could not be added as a member
+			ee.removeFromNominees(host)
+		}
 		return err
 	}
 
@@ -222,8 +456,7 @@ func (ee *ElasticEtcd) nominate(host string, urls types.URLs) error {
 }
 
 func (ee *ElasticEtcd) addToNominees(host string, urls types.URLs) error {
-	key := nomineePrefix + host
-	_, err := ee.cli.Put(ee.cli.Ctx(), key, urls.String())
+	err := ee.putNomination(ee.cli.Ctx(), host, &nomination{PURLs: urls.String()})
 	if err != nil {
 		ee.log.WithError(err).WithField("host", host).Error("failed to add host to nominees list")
 	}
@@ -234,6 +467,18 @@ func (ee *ElasticEtcd) removeNomination(host string) error {
 	logger := ee.log.WithField("host", host)
 	logger.Debug("removing nomination for host")
 
+	// The peer URLs recorded in the nomination find the member if the host
+	// hasn't started its server yet
+	n, err := ee.getNomination(ee.cli.Ctx(), host)
+	if err != nil {
+		logger.WithError(err).Error("failed to get nomination for host")
+		return err
+	}
+	var purls []string
+	if n != nil {
+		purls = n.urls()
+	}
+
 	// Remove host from nomination list
 	if err := ee.removeFromNominees(host); err != nil {
 		return err
@@ -245,11 +490,10 @@ func (ee *ElasticEtcd) removeNomination(host string) error {
 		logger.WithError(err).Error("failed to get memberlist while trying to remove nomination for host")
 		return err
 	}
-	var m *etcdserverpb.Member
-	for _, m = range memlist.Members {
-		if m.Name == host {
-			break
-		}
+	m := findMember(memlist.Members, host, purls)
+	if m == nil {
+		logger.Debug("host is not an etcd cluster member")
+		return nil
 	}
 	_, err = ee.cli.MemberRemove(ee.cli.Ctx(), m.ID)
 	if err != nil {
@@ -0,0 +1,138 @@
+package elasticetcd
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+// maintenanceTimeout is the time allowed for a compaction, or for the
+// defragmentation of a single server
+var maintenanceTimeout = 5 * time.Minute
+
+// startMaintenance starts compacting the history of the etcd cluster and
+// defragmenting the backends of the etcd servers periodically, till the given
+// stop channel is closed. A zero interval disables the respective task.
+func (ee *ElasticEtcd) startMaintenance(stop <-chan struct{}) {
+	ee.lock.RLock()
+	compaction, defrag := ee.conf.CompactionInterval, ee.conf.DefragInterval
+	ee.lock.RUnlock()
+
+	if compaction > 0 {
+		ee.log.WithField("interval", compaction).Debug("starting periodic compaction")
+		ee.every(stop, compaction, ee.compactor())
+	}
+	if defrag > 0 {
+		ee.log.WithField("interval", defrag).Debug("starting periodic defragmentation")
+		ee.every(stop, defrag, ee.defragment)
+	}
+}
+
+// every runs the given task at the given interval, till the given stop
+// channel or the stopwatching channel is closed
+func (ee *ElasticEtcd) every(stop <-chan struct{}, interval time.Duration, task func()) {
+	ee.watchers.Add(1)
+	go func() {
+		defer ee.watchers.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				task()
+			case <-ee.stopwatching:
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// compactor returns a task which compacts the history of the etcd cluster up
+// to the revision seen when it previously ran, so that the history of one
+// interval is always kept
+func (ee *ElasticEtcd) compactor() func() {
+	var rev int64
+	return func() {
+		ctx, cancel := context.WithTimeout(ee.cli.Ctx(), maintenanceTimeout)
+		defer cancel()
+
+		// Any key will do, only the revision of the response is needed
+		resp, err := ee.cli.Get(ctx, eePrefix, clientv3.WithCountOnly())
+		if err != nil {
+			ee.log.WithError(err).Warn("could not get current revision for compaction")
+			return
+		}
+
+		if rev > 0 {
+			_, err := ee.cli.Compact(ctx, rev, clientv3.WithCompactPhysical())
+			switch err {
+			case nil:
+				ee.log.WithField("revision", rev).Debug("compacted etcd history")
+			case rpctypes.ErrCompacted:
+				// Already compacted further, by someone else
+			default:
+				ee.log.WithError(err).WithField("revision", rev).Warn("failed to compact etcd history")
+				return
+			}
+		}
+		rev = resp.Header.Revision
+	}
+}
+
+// defragment defragments the backends of the etcd servers one at a time, as
+// a server doesn't respond while it is being defragmented. Space alarms are
+// disarmed afterwards, as the space freed by compactions is only reclaimed
+// by defragmentation.
+func (ee *ElasticEtcd) defragment() {
+	memlist, err := ee.cli.MemberList(ee.cli.Ctx())
+	if err != nil {
+		ee.log.WithError(err).Warn("could not get memberlist for defragmentation")
+		return
+	}
+
+	var failed bool
+	for _, m := range memlist.Members {
+		// Members which haven't started have no client URLs
+		if len(m.ClientURLs) == 0 {
+			continue
+		}
+		logger := ee.log.WithField("member", m.Name)
+
+		ctx, cancel := context.WithTimeout(ee.cli.Ctx(), maintenanceTimeout)
+		_, err := ee.cli.Defragment(ctx, m.ClientURLs[0])
+		cancel()
+		if err != nil {
+			logger.WithError(err).Warn("failed to defragment etcd server")
+			failed = true
+			continue
+		}
+		logger.Debug("defragmented etcd server")
+	}
+	if failed {
+		return
+	}
+
+	alarms, err := ee.cli.AlarmList(ee.cli.Ctx())
+	if err != nil {
+		ee.log.WithError(err).Warn("could not get alarms after defragmentation")
+		return
+	}
+	for _, a := range alarms.Alarms {
+		if a.Alarm != etcdserverpb.AlarmType_NOSPACE {
+			continue
+		}
+		am := &clientv3.AlarmMember{MemberID: a.MemberID, Alarm: a.Alarm}
+		if _, err := ee.cli.AlarmDisarm(ee.cli.Ctx(), am); err != nil {
+			ee.log.WithError(err).WithField("memberid", types.ID(a.MemberID)).Warn("failed to disarm space alarm")
+			continue
+		}
+		ee.log.WithField("memberid", types.ID(a.MemberID)).Info("disarmed space alarm after defragmentation")
+	}
+}
@@ -0,0 +1,42 @@
+package elasticetcd
+
+import (
+	"testing"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+// TestCompactor validates that the compactor keeps the history since it
+// previously ran
+func TestCompactor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd test in short mode")
+	}
+
+	ee, cleanup := newTestLeader(t, 1)
+	defer cleanup()
+
+	put := func(v string) int64 {
+		resp, err := ee.cli.Put(ee.cli.Ctx(), "test", v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Revision
+	}
+
+	compact := ee.compactor()
+
+	first := put("1")
+	// Only records the revision to compact at next time
+	compact()
+	second := put("2")
+	compact()
+
+	if _, err := ee.cli.Get(ee.cli.Ctx(), "test", clientv3.WithRev(first-1)); err != rpctypes.ErrCompacted {
+		t.Errorf("expected history before revision %d to be compacted, got %v", first, err)
+	}
+	if _, err := ee.cli.Get(ee.cli.Ctx(), "test", clientv3.WithRev(second)); err != nil {
+		t.Errorf("expected history since revision %d to be kept, got %v", first, err)
+	}
+}
@@ -0,0 +1,251 @@
+package elasticetcd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+)
+
+const (
+	pinnedPrefix   = eePrefix + "/pinned/"
+	excludedPrefix = eePrefix + "/excluded/"
+
+	// statusTimeout is the time allowed for an etcd server to report its
+	// status
+	statusTimeout = 2 * time.Second
+)
+
+// NominationPolicy decides how the leader nominates a volunteer
+type NominationPolicy string
+
+const (
+	// NominationAuto volunteers are nominated as required to keep the
+	// ideal size
+	NominationAuto NominationPolicy = "auto"
+	// NominationPinned volunteers are always nominated, even if the ideal
+	// size is exceeded
+	NominationPinned NominationPolicy = "pinned"
+	// NominationExcluded volunteers are never nominated
+	NominationExcluded NominationPolicy = "excluded"
+)
+
+// Validate checks that the nomination policy is known
+func (p NominationPolicy) Validate() error {
+	switch p {
+	case NominationAuto, NominationPinned, NominationExcluded:
+		return nil
+	}
+	return ErrInvalidNominationPolicy
+}
+
+// Member describes an instance taking part in the elastic cluster, either as
+// a volunteer, as a nominee, or as an etcd cluster member
+type Member struct {
+	Name      string
+	Volunteer bool
+	Nominee   bool
+	Leader    bool
+	Policy    NominationPolicy
+	Domain    FailureDomain
+	PURLs     []string
+	CURLs     []string
+
+	// Server is true if the instance is an etcd cluster member
+	Server bool
+	// Healthy is true if the etcd server of the instance reports its
+	// status. Error is the reason it couldn't.
+	Healthy bool
+	Error   string
+}
+
+// Members returns the members of the elastic cluster, sorted by name
+func (ee *ElasticEtcd) Members(ctx context.Context) ([]*Member, error) {
+	members := make(map[string]*Member)
+	member := func(name string) *Member {
+		m, ok := members[name]
+		if !ok {
+			m = &Member{Name: name, Policy: NominationAuto}
+			members[name] = m
+		}
+		return m
+	}
+
+	volunteersResp, err := ee.cli.Get(ctx, volunteerPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	volunteers, err := urlsMapFromGetResp(volunteersResp, volunteerPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for name, urls := range volunteers {
+		m := member(name)
+		m.Volunteer = true
+		m.PURLs = urls.StringSlice()
+	}
+
+	nomineesResp, err := ee.cli.Get(ctx, nomineePrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range keysFromGetResp(nomineesResp, nomineePrefix) {
+		member(name).Nominee = true
+	}
+
+	domains, err := ee.failureDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name, d := range domains {
+		member(name).Domain = d
+	}
+
+	pinned, excluded, err := ee.nominationPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range pinned {
+		member(name).Policy = NominationPinned
+	}
+	for _, name := range excluded {
+		member(name).Policy = NominationExcluded
+	}
+
+	leader, err := ee.Leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leader != "" {
+		member(leader).Leader = true
+	}
+
+	memlist, err := ee.cli.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, em := range memlist.Members {
+		// Members which were added but haven't started yet have no name
+		if em.Name == "" {
+			continue
+		}
+		m := member(em.Name)
+		m.Server = true
+		m.PURLs = em.PeerURLs
+		m.CURLs = em.ClientURLs
+		m.Healthy, m.Error = ee.serverHealth(ctx, em.ClientURLs)
+	}
+
+	list := make([]*Member, 0, len(members))
+	for _, m := range members {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// serverHealth returns true if the etcd server with the given client URLs
+// reports its status, or else the reason it didn't
+func (ee *ElasticEtcd) serverHealth(ctx context.Context, curls []string) (bool, string) {
+	err := errors.New("no client urls")
+	for _, u := range curls {
+		sctx, cancel := context.WithTimeout(ctx, statusTimeout)
+		_, err = ee.cli.Status(sctx, u)
+		cancel()
+		if err == nil {
+			return true, ""
+		}
+	}
+	return false, err.Error()
+}
+
+// Leader returns the name of the leader of the elastic cluster, or an empty
+// string if there is no leader
+func (ee *ElasticEtcd) Leader(ctx context.Context) (string, error) {
+	resp, err := concurrency.NewElection(ee.Session(), electionKey).Leader(ctx)
+	switch {
+	case err == concurrency.ErrElectionNoLeader:
+		return "", nil
+	case err != nil:
+		return "", err
+	case len(resp.Kvs) == 0:
+		return "", nil
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+// IdealSize returns the ideal size of the elastic cluster
+func (ee *ElasticEtcd) IdealSize(ctx context.Context) (int, error) {
+	resp, err := ee.cli.Get(ctx, idealSizeKey)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Count == 0 {
+		// The ideal size hasn't been changed from the configured one
+		ee.lock.RLock()
+		defer ee.lock.RUnlock()
+		return ee.conf.IdealSize, nil
+	}
+	return strconv.Atoi(string(resp.Kvs[0].Value))
+}
+
+// ValidateIdealSize checks that the given size can be set as the ideal size
+// of the elastic cluster
+func ValidateIdealSize(size int) error {
+	if size < 1 {
+		return ErrInvalidIdealSize
+	}
+	return nil
+}
+
+// SetIdealSize sets the ideal size of the elastic cluster. The leader
+// nominates more servers or removes nominations to reach it.
+func (ee *ElasticEtcd) SetIdealSize(ctx context.Context, size int) error {
+	if err := ValidateIdealSize(size); err != nil {
+		return err
+	}
+	_, err := ee.cli.Put(ctx, idealSizeKey, strconv.Itoa(size))
+	return err
+}
+
+// SetNominationPolicy sets the nomination policy of the instance with the
+// given name. The leader nominates servers or removes nominations to follow
+// it.
+func (ee *ElasticEtcd) SetNominationPolicy(ctx context.Context, name string, policy NominationPolicy) error {
+	var ops []clientv3.Op
+	switch policy {
+	case NominationAuto:
+		ops = []clientv3.Op{clientv3.OpDelete(pinnedPrefix + name), clientv3.OpDelete(excludedPrefix + name)}
+	case NominationPinned:
+		ops = []clientv3.Op{clientv3.OpPut(pinnedPrefix+name, ""), clientv3.OpDelete(excludedPrefix + name)}
+	case NominationExcluded:
+		ops = []clientv3.Op{clientv3.OpDelete(pinnedPrefix + name), clientv3.OpPut(excludedPrefix+name, "")}
+	default:
+		return ErrInvalidNominationPolicy
+	}
+	_, err := ee.cli.Txn(ctx).Then(ops...).Commit()
+	return err
+}
+
+// nominationPolicies returns the names of the pinned and of the excluded
+// instances, sorted by name
+func (ee *ElasticEtcd) nominationPolicies(ctx context.Context) ([]string, []string, error) {
+	sortOpt := clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)
+
+	pinnedResp, err := ee.cli.Get(ctx, pinnedPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(), sortOpt)
+	if err != nil {
+		return nil, nil, err
+	}
+	excludedResp, err := ee.cli.Get(ctx, excludedPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(), sortOpt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keysFromGetResp(pinnedResp, pinnedPrefix), keysFromGetResp(excludedResp, excludedPrefix), nil
+}
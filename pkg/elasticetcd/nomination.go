@@ -0,0 +1,195 @@
+package elasticetcd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+var (
+	// memberAddTimeout is the time a nominee waits to be added as an etcd
+	// cluster member, before waiting for its nomination to be updated
+	memberAddTimeout = 30 * time.Second
+	// memberAddPollInterval is the interval at which a nominee checks if
+	// it has been added as an etcd cluster member
+	memberAddPollInterval = 500 * time.Millisecond
+	// nominationRetryInterval is the time after which a nominee which
+	// wasn't added as an etcd cluster member in time handles its
+	// nomination again
+	nominationRetryInterval = 30 * time.Second
+	// ackTimeout is the time allowed for the leader to acknowledge a
+	// member add in the nomination. The acknowledgement can only be
+	// written once the nominee starts its server, if the cluster had a
+	// single server before the nominee was added.
+	ackTimeout = 2 * time.Minute
+)
+
+// nomination is the record of a nominee. The leader first records the
+// nomination with the peer URLs of the nominee, then adds the nominee as an
+// etcd cluster member, and then acknowledges the member add by recording the
+// member ID and the initial cluster the nominee must start its server with.
+type nomination struct {
+	PURLs          string `json:"purls"`
+	MemberID       uint64 `json:"member-id,omitempty"`
+	InitialCluster string `json:"initial-cluster,omitempty"`
+}
+
+// parseNomination parses a nomination record. Older leaders only recorded the
+// peer URLs of the nominees.
+func parseNomination(b []byte) (*nomination, error) {
+	n := new(nomination)
+	if len(b) > 0 && b[0] == '{' {
+		if err := json.Unmarshal(b, n); err != nil {
+			return nil, err
+		}
+	} else {
+		n.PURLs = string(b)
+	}
+	return n, nil
+}
+
+// urls returns the peer URLs of the nominee
+func (n *nomination) urls() []string {
+	if n.PURLs == "" {
+		return nil
+	}
+	return strings.Split(n.PURLs, ",")
+}
+
+// initialCluster returns the initial cluster of the new member with the
+// given ID and name, from the list of the etcd cluster members. Like etcdctl
+// does, members which haven't started yet are included without names.
+func initialCluster(members []*etcdserverpb.Member, id uint64, name string) string {
+	var cluster []string
+	for _, m := range members {
+		n := m.Name
+		if m.ID == id {
+			n = name
+		}
+		for _, u := range m.PeerURLs {
+			cluster = append(cluster, n+"="+u)
+		}
+	}
+	return strings.Join(cluster, ",")
+}
+
+// findMember returns the member with the given name, or one of the given
+// peer URLs, as members which haven't started yet have no names
+func findMember(members []*etcdserverpb.Member, name string, purls []string) *etcdserverpb.Member {
+	for _, m := range members {
+		if m.Name != "" && m.Name == name {
+			return m
+		}
+		if len(intersectStringSlices(m.PeerURLs, purls)) != 0 {
+			return m
+		}
+	}
+	return nil
+}
+
+// getNomination returns the nomination record of the given host, or nil if
+// it isn't a nominee
+func (ee *ElasticEtcd) getNomination(ctx context.Context, host string, opts ...clientv3.OpOption) (*nomination, error) {
+	resp, err := ee.cli.Get(ctx, nomineePrefix+host, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Count == 0 {
+		return nil, nil
+	}
+	return parseNomination(resp.Kvs[0].Value)
+}
+
+func (ee *ElasticEtcd) putNomination(ctx context.Context, host string, n *nomination) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = ee.cli.Put(ctx, nomineePrefix+host, string(b))
+	return err
+}
+
+// completeNomination adds the nominee as an etcd cluster member, if it isn't
+// one yet, and acknowledges the member add in its nomination
+func (ee *ElasticEtcd) completeNomination(host string, n *nomination) error {
+	logger := ee.log.WithField("host", host)
+
+	memlist, err := ee.cli.MemberList(ee.cli.Ctx())
+	if err != nil {
+		logger.WithError(err).Error("failed to get memberlist while trying to complete nomination for host")
+		return err
+	}
+	members := memlist.Members
+
+	m := findMember(members, host, n.urls())
+	if m == nil {
+		resp, err := ee.cli.MemberAdd(ee.cli.Ctx(), n.urls())
+		if err != nil {
+			logger.WithError(err).Error("failed to add host as etcd cluster member")
+			return err
+		}
+		m = resp.Member
+		n.MemberID = m.ID
+
+		// The list is needed to form the initial cluster, and the
+		// membership is served by the local server, so it is available
+		// even if the cluster has lost quorum with the new member
+		memlist, err = ee.cli.MemberList(ee.cli.Ctx())
+		if err != nil {
+			logger.WithError(err).Error("failed to get memberlist after adding host as etcd cluster member")
+			return err
+		}
+		members = memlist.Members
+	}
+
+	n.MemberID = m.ID
+	n.InitialCluster = initialCluster(members, m.ID, host)
+
+	// If the cluster had a single server, the new member is needed for
+	// quorum, and this completes only after the nominee has started its
+	// server after seeing itself in the member list
+	ctx, cancel := context.WithTimeout(ee.cli.Ctx(), ackTimeout)
+	defer cancel()
+	if err := ee.putNomination(ctx, host, n); err != nil {
+		logger.WithError(err).Warn("failed to acknowledge member add in nomination")
+		return err
+	}
+	logger.WithField("memberid", types.ID(m.ID)).Debug("acknowledged member add in nomination")
+
+	return nil
+}
+
+// waitForMemberAdd waits for the nominee with the given peer URLs to be added
+// as an etcd cluster member, and returns the initial cluster to start its
+// server with. ErrMemberAddTimeout is returned if it isn't added in time.
+func (ee *ElasticEtcd) waitForMemberAdd(purls []string) (string, error) {
+	// This is called without ee.lock held, so the client is only read once
+	cli := ee.Client()
+	if cli == nil {
+		return "", ErrClientNotAvailable
+	}
+	ctx, cancel := context.WithTimeout(cli.Ctx(), memberAddTimeout)
+	defer cancel()
+
+	for {
+		// The membership is served by the local server, so it can be
+		// read even if the cluster has no quorum till this server starts
+		memlist, err := cli.MemberList(ctx)
+		if err == nil {
+			if m := findMember(memlist.Members, ee.conf.Name, purls); m != nil {
+				return initialCluster(memlist.Members, m.ID, ee.conf.Name), nil
+			}
+		}
+
+		select {
+		case <-time.After(memberAddPollInterval):
+		case <-ctx.Done():
+			return "", ErrMemberAddTimeout
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package elasticetcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+func TestParseNomination(t *testing.T) {
+	n, err := parseNomination([]byte("http://a:2380,http://b:2380"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.MemberID != 0 || !compareStringSlices(n.urls(), []string{"http://a:2380", "http://b:2380"}) {
+		t.Errorf("parseNomination(): unexpected nomination %+v from peer URLs", n)
+	}
+
+	n, err = parseNomination([]byte(`{"purls":"http://a:2380","member-id":42,"initial-cluster":"a=http://a:2380"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.MemberID != 42 || n.InitialCluster != "a=http://a:2380" || !compareStringSlices(n.urls(), []string{"http://a:2380"}) {
+		t.Errorf("parseNomination(): unexpected nomination %+v from record", n)
+	}
+
+	if _, err := parseNomination([]byte("{")); err == nil {
+		t.Error("parseNomination(): expected error for invalid record")
+	}
+}
+
+func TestInitialCluster(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "a", PeerURLs: []string{"http://a:2380"}},
+		{ID: 2, PeerURLs: []string{"http://b:2380"}},
+		{ID: 3, PeerURLs: []string{"http://c:2380"}},
+	}
+
+	expected := "a=http://a:2380,b=http://b:2380,=http://c:2380"
+	if c := initialCluster(members, 2, "b"); c != expected {
+		t.Errorf("initialCluster(): expected %s, got %s", expected, c)
+	}
+
+	if m := findMember(members, "b", []string{"http://b:2380"}); m == nil || m.ID != 2 {
+		t.Errorf("findMember(): expected to find unstarted member by peer URLs, got %v", m)
+	}
+	if m := findMember(members, "a", nil); m == nil || m.ID != 1 {
+		t.Errorf("findMember(): expected to find member by name, got %v", m)
+	}
+	if m := findMember(members, "d", []string{"http://d:2380"}); m != nil {
+		t.Errorf("findMember(): expected no member, got %v", m)
+	}
+}
+
+// TestNominationAcknowledged validates that the leader acknowledges member
+// adds in the nominations, including those left unacknowledged by a previous
+// leader
+func TestNominationAcknowledged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd test in short mode")
+	}
+
+	ee, cleanup := newTestLeader(t, 3)
+	defer cleanup()
+
+	volunteer(t, ee, "leader", FailureDomain{})
+	volunteer(t, ee, "a", FailureDomain{})
+	volunteer(t, ee, "b", FailureDomain{})
+	// b was nominated, but never added as a member
+	if err := ee.addToNominees("b", types.MustNewURLs([]string{"http://b:2380"})); err != nil {
+		t.Fatal(err)
+	}
+
+	ee.doNominations()
+	checkNominees(t, ee, "a", "b", "leader")
+
+	for _, h := range []string{"a", "b"} {
+		n, err := ee.getNomination(ee.cli.Ctx(), h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == nil || n.MemberID == 0 {
+			t.Fatalf("expected member add of %s to be acknowledged, got %+v", h, n)
+		}
+		m, ok := ee.cli.Cluster.(*fakeCluster).members[n.MemberID]
+		if !ok || m.Name != h {
+			t.Errorf("expected %s to be added as member %d", h, n.MemberID)
+		}
+		if !strings.Contains(n.InitialCluster, h+"=http://"+h+":2380") {
+			t.Errorf("expected %s in initial cluster, got %s", h, n.InitialCluster)
+		}
+	}
+}
+
+// blockingCluster is a fakeCluster whose member adds block till unblock is
+// closed
+type blockingCluster struct {
+	*fakeCluster
+
+	adding  chan struct{}
+	unblock chan struct{}
+}
+
+func (c *blockingCluster) MemberAdd(ctx context.Context, peerAddrs []string) (*clientv3.MemberAddResponse, error) {
+	c.adding <- struct{}{}
+	<-c.unblock
+	return c.fakeCluster.MemberAdd(ctx, peerAddrs)
+}
+
+// TestNominationWithoutLock validates that the leader doesn't hold its lock
+// while completing nominations, which may wait for the nominees
+func TestNominationWithoutLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd test in short mode")
+	}
+
+	ee, cleanup := newTestLeader(t, 2)
+	defer cleanup()
+
+	c := &blockingCluster{
+		fakeCluster: ee.cli.Cluster.(*fakeCluster),
+		adding:      make(chan struct{}),
+		unblock:     make(chan struct{}),
+	}
+	ee.cli.Cluster = c
+	defer func() {
+		ee.cli.Cluster = c.fakeCluster
+	}()
+
+	volunteer(t, ee, "leader", FailureDomain{})
+	volunteer(t, ee, "a", FailureDomain{})
+
+	done := make(chan struct{})
+	go func() {
+		ee.doNominations()
+		close(done)
+	}()
+
+	select {
+	case <-c.adding:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for member add")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		ee.lock.Lock()
+		ee.lock.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Error("lock held while adding nominee as member")
+	}
+
+	close(c.unblock)
+	<-done
+	checkNominees(t, ee, "a", "leader")
+}
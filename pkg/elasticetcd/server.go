@@ -108,6 +108,18 @@ func (ee *ElasticEtcd) newEmbedConfig(initialCluster string) *embed.Config {
 		conf.APUrls = defaultAPURLs
 	}
 
+	conf.LCUrls = ee.conf.ClientTLS.SecureURLs(conf.LCUrls)
+	conf.ACUrls = ee.conf.ClientTLS.SecureURLs(conf.ACUrls)
+	conf.LPUrls = ee.conf.PeerTLS.SecureURLs(conf.LPUrls)
+	conf.APUrls = ee.conf.PeerTLS.SecureURLs(conf.APUrls)
+
+	// The self-signed certificates for auto TLS are generated only if no
+	// certificate is given
+	conf.ClientTLSInfo = ee.conf.ClientTLS.serverInfo()
+	conf.ClientAutoTLS = ee.conf.ClientTLS.AutoTLS
+	conf.PeerTLSInfo = ee.conf.PeerTLS.serverInfo()
+	conf.PeerAutoTLS = ee.conf.PeerTLS.AutoTLS
+
 	if initialCluster != "" {
 		conf.InitialCluster = initialCluster
 		conf.ClusterState = embed.ClusterStateFlagExisting
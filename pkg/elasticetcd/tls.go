@@ -0,0 +1,89 @@
+package elasticetcd
+
+import (
+	"crypto/tls"
+
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+// TLSInfo holds the TLS options for the client or the peer connections of the
+// etcd servers. The same options are used on both sides of the connections,
+// as every ElasticEtcd can be both a client and a server.
+type TLSInfo struct {
+	// CertFile and KeyFile are the certificate and key presented by the
+	// servers, and by the clients when CAFile is set
+	CertFile, KeyFile string
+	// CAFile is the CA used to verify the servers. If set, the servers also
+	// require the clients to present certificates signed by it.
+	CAFile string
+	// AutoTLS uses generated self-signed certificates, if no certificate is
+	// given. The self-signed certificates can't be verified.
+	AutoTLS bool
+}
+
+// Empty returns true if no TLS option is set
+func (t TLSInfo) Empty() bool {
+	return t.CertFile == "" && t.KeyFile == "" && t.CAFile == "" && !t.AutoTLS
+}
+
+// serverInfo returns the etcd TLS info for the servers
+func (t TLSInfo) serverInfo() transport.TLSInfo {
+	return transport.TLSInfo{
+		CertFile:       t.CertFile,
+		KeyFile:        t.KeyFile,
+		TrustedCAFile:  t.CAFile,
+		ClientCertAuth: t.CAFile != "",
+	}
+}
+
+// ClientConfig returns the TLS config for clients connecting to servers using
+// these options, or nil if TLS isn't used
+func (t TLSInfo) ClientConfig() (*tls.Config, error) {
+	switch {
+	case t.Empty():
+		return nil, nil
+	case t.CertFile == "" && t.CAFile == "":
+		// The servers use self-signed certificates
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	info := transport.TLSInfo{
+		TrustedCAFile: t.CAFile,
+	}
+	// The certificate is only needed by the client if the servers verify
+	// it
+	if t.CAFile != "" {
+		info.CertFile = t.CertFile
+		info.KeyFile = t.KeyFile
+	}
+	return info.ClientConfig()
+}
+
+// Validate returns an error if the certificate, key or CA files of the options
+// can't be loaded
+func (t TLSInfo) Validate() error {
+	if t.CertFile != "" || t.KeyFile != "" {
+		if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return err
+		}
+	}
+	_, err := t.ClientConfig()
+	return err
+}
+
+// SecureURLs returns the given URLs, with the https scheme instead of the http
+// scheme if TLS is used
+func (t TLSInfo) SecureURLs(urls types.URLs) types.URLs {
+	if t.Empty() {
+		return urls
+	}
+	secure := make(types.URLs, 0, len(urls))
+	for _, u := range urls {
+		if u.Scheme == "http" {
+			u.Scheme = "https"
+		}
+		secure = append(secure, u)
+	}
+	return secure
+}
@@ -0,0 +1,56 @@
+package elasticetcd
+
+import (
+	"testing"
+
+	"github.com/coreos/etcd/pkg/types"
+)
+
+func TestSecureURLs(t *testing.T) {
+	urls := types.MustNewURLs([]string{"http://a:2379", "https://b:2379", "unix://c:2379"})
+
+	if s := (TLSInfo{}).SecureURLs(urls).String(); s != urls.String() {
+		t.Errorf("SecureURLs(): expected URLs to be unchanged without TLS, got %s", s)
+	}
+
+	expected := "https://a:2379,https://b:2379,unix://c:2379"
+	if s := (TLSInfo{AutoTLS: true}).SecureURLs(urls).String(); s != expected {
+		t.Errorf("SecureURLs(): expected %s, got %s", expected, s)
+	}
+	if urls[0].Scheme != "http" {
+		t.Error("SecureURLs(): given URLs were modified")
+	}
+}
+
+func TestTLSClientConfig(t *testing.T) {
+	c, err := (TLSInfo{}).ClientConfig()
+	if err != nil || c != nil {
+		t.Errorf("ClientConfig(): expected no TLS config without TLS, got %v, %v", c, err)
+	}
+
+	c, err = (TLSInfo{AutoTLS: true}).ClientConfig()
+	if err != nil || c == nil || !c.InsecureSkipVerify {
+		t.Errorf("ClientConfig(): expected TLS config skipping verification with auto TLS, got %v, %v", c, err)
+	}
+
+	if _, err := (TLSInfo{CAFile: "/nonexistent/ca.crt"}).ClientConfig(); err == nil {
+		t.Error("ClientConfig(): expected error for missing CA file")
+	}
+}
+
+func TestTLSValidate(t *testing.T) {
+	if err := (TLSInfo{}).Validate(); err != nil {
+		t.Errorf("Validate(): expected no error without TLS, got %v", err)
+	}
+	if err := (TLSInfo{AutoTLS: true}).Validate(); err != nil {
+		t.Errorf("Validate(): expected no error with auto TLS, got %v", err)
+	}
+
+	// Certificates are checked even if the clients don't present them
+	if err := (TLSInfo{CertFile: "/nonexistent/server.crt", KeyFile: "/nonexistent/server.key"}).Validate(); err == nil {
+		t.Error("Validate(): expected error for missing certificate")
+	}
+	if err := (TLSInfo{CAFile: "/nonexistent/ca.crt"}).Validate(); err == nil {
+		t.Error("Validate(): expected error for missing CA file")
+	}
+}
@@ -81,3 +81,32 @@ func diffStringSlices(a, b []string) []string {
 
 	return diff
 }
+
+// intersectStringSlices returns a slice with the items of the first which are
+// also in the second
+func intersectStringSlices(a, b []string) []string {
+	var common []string
+
+	bmap := make(map[string]bool)
+	for _, v := range b {
+		bmap[v] = true
+	}
+
+	for _, v := range a {
+		if bmap[v] {
+			common = append(common, v)
+		}
+	}
+
+	return common
+}
+
+// containsString returns true if the slice contains the given string
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
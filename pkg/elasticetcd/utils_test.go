@@ -46,3 +46,25 @@ func TestDiffStringSlices(t *testing.T) {
 		}
 	}
 }
+
+func TestIntersectStringSlices(t *testing.T) {
+	a := []string{"1", "2", "3", "4"}
+	b := []string{"5", "6", "7", "8"}
+
+	tests := []struct {
+		a, b, expected []string
+	}{
+		{a, a, a},
+		{a, b, []string{}},
+		{a, a[0:2], []string{"1", "2"}},
+		{a, append([]string{"3"}, b...), []string{"3"}},
+		{nil, a, []string{}},
+	}
+
+	for _, i := range tests {
+		r := intersectStringSlices(i.a, i.b)
+		if !compareStringSlices(i.expected, r) {
+			t.Errorf("intersectStringSlices(%v, %v): expected %v, got %v", i.a, i.b, i.expected, r)
+		}
+	}
+}
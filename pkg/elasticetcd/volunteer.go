@@ -1,6 +1,7 @@
 package elasticetcd
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
@@ -8,24 +9,44 @@ import (
 
 // volunteerSelf adds the self to the volunteer list and starts watching for the nomination
 func (ee *ElasticEtcd) volunteerSelf() error {
+	if err := ee.putVolunteer(); err != nil {
+		return err
+	}
+	ee.watchNomination()
+
+	return nil
+}
+
+// putVolunteer adds the self to the volunteer list, with its failure domain.
+// The volunteer key is attached to the session lease, and is removed when the
+// session ends.
+func (ee *ElasticEtcd) putVolunteer() error {
 	key := volunteerPrefix + ee.conf.Name
 	var val string
 	// Need to set advertisable PURLs here as the initial cluster lists for new
 	// servers will be formed from this, the default PURL is not advertisable.
 	if isDefaultPURL(ee.conf.PURLs) {
-		val = defaultAPURLs.String()
+		val = ee.conf.PeerTLS.SecureURLs(defaultAPURLs).String()
 	} else {
-		val = ee.conf.PURLs.String()
+		val = ee.conf.PeerTLS.SecureURLs(ee.conf.PURLs).String()
 	}
 
-	_, err := ee.cli.Put(ee.cli.Ctx(), key, val, clientv3.WithLease(ee.session.Lease()))
+	// Publish the failure domain along with the volunteer key, so that the
+	// leader knows it when the volunteer list changes
+	domain, err := json.Marshal(ee.conf.Domain)
 	if err != nil {
-		ee.log.WithError(err).Error("failed to add self to volunteer list")
 		return err
 	}
-	ee.watchNomination()
 
-	return nil
+	lease := clientv3.WithLease(ee.session.Lease())
+	_, err = ee.cli.Txn(ee.cli.Ctx()).Then(
+		clientv3.OpPut(domainPrefix+ee.conf.Name, string(domain), lease),
+		clientv3.OpPut(key, val, lease),
+	).Commit()
+	if err != nil {
+		ee.log.WithError(err).Error("failed to add self to volunteer list")
+	}
+	return err
 }
 
 func (ee *ElasticEtcd) watchNomination() {
@@ -39,33 +60,94 @@ func (ee *ElasticEtcd) watchNomination() {
 	ee.watch(key, f)
 }
 
+// handleNomination starts or stops the embedded server of this ElasticEtcd as
+// per its nomination. Waiting to be added as an etcd cluster member is done
+// without holding ee.lock, and is retried after nominationRetryInterval if it
+// times out.
 func (ee *ElasticEtcd) handleNomination() {
+	ee.log.Debug("handling nomination")
+
+	n, ok := ee.checkNomination()
+	if !ok {
+		return
+	}
+
+	initialCluster := n.InitialCluster
+	if n.MemberID == 0 {
+		// The leader may not be able to acknowledge the member add before
+		// your server starts, so watch the member list as well. If you
+		// aren't added in time, the acknowledgement triggers this again,
+		// and so does the retry.
+		ee.log.Debug("nominated, waiting to be added as etcd cluster member")
+		var err error
+		initialCluster, err = ee.waitForMemberAdd(n.urls())
+		if err != nil {
+			ee.log.WithError(err).WithField("retry-in", nominationRetryInterval).Warn("not added as etcd cluster member, waiting for nomination to be acknowledged")
+			ee.retryNomination()
+			return
+		}
+	}
+
 	ee.lock.Lock()
 	defer ee.lock.Unlock()
 
-	ee.log.Debug("handling nomination")
-
-	// Get the current list of nominees
-	nomineesResp, err := ee.cli.Get(ee.cli.Ctx(), nomineePrefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
-	if err != nil {
-		ee.log.WithError(err).Error("could not get nominees")
+	// The nomination may have been removed, or handled by another call,
+	// while waiting
+	if ee.stopping || ee.server.srv != nil {
 		return
 	}
+	if n, err := ee.getNomination(ee.cli.Ctx(), ee.conf.Name, clientv3.WithSerializable()); err != nil || n == nil {
+		ee.log.Debug("nomination removed while waiting to be added as etcd cluster member")
+		return
+	}
+
+	ee.log.WithField("initialcluster", initialCluster).Debug("nominated, starting server")
+	if err := ee.startServer(initialCluster); err != nil {
+		ee.log.WithError(err).Error("failed to start server after nomination")
+	}
+}
+
+// checkNomination gets the nomination of this ElasticEtcd, and stops its
+// embedded server if it isn't nominated anymore. The nomination is returned if
+// the server needs to be started.
+func (ee *ElasticEtcd) checkNomination() (*nomination, bool) {
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	if ee.stopping {
+		return nil, false
+	}
 
-	nominees, err := urlsMapFromGetResp(nomineesResp, nomineePrefix)
+	// Get your nomination from the local server, as the cluster may not
+	// have quorum till your server starts
+	n, err := ee.getNomination(ee.cli.Ctx(), ee.conf.Name, clientv3.WithSerializable())
 	if err != nil {
-		ee.log.WithError(err).Error("could not prepare nominees map")
+		ee.log.WithError(err).Error("could not get nomination")
+		return nil, false
 	}
 
 	// Check if you are in the nominees, and start/stop you embedded server as
 	// required
-	if _, ok := nominees[ee.conf.Name]; ok {
-		ee.log.Debug("nominated, starting server")
-		// Sleeping to allow leader to add me as a etcd cluster member
-		time.Sleep(2 * time.Second)
-		ee.startServer(nominees.String())
-	} else {
+	if n == nil {
 		ee.log.Debug("not nominated or nomination removed, stopping server")
 		ee.stopServer()
+		return nil, false
+	}
+	if ee.server.srv != nil {
+		// The nomination was acknowledged after the server started
+		return nil, false
 	}
+	return n, true
+}
+
+// retryNomination handles the nomination again after nominationRetryInterval,
+// unless ElasticEtcd is stopped before
+func (ee *ElasticEtcd) retryNomination() {
+	go func() {
+		select {
+		case <-time.After(nominationRetryInterval):
+			ee.handleNomination()
+		case <-ee.stopwatching:
+		}
+	}()
 }
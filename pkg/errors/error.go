@@ -16,6 +16,7 @@ var (
 	ErrVolExists               = errors.New("volume already exists")
 	ErrVolAlreadyStarted       = errors.New("volume already started")
 	ErrVolAlreadyStopped       = errors.New("volume already stopped")
+	ErrVolNotStopped           = errors.New("volume is not stopped")
 	ErrWrongGraphType          = errors.New("graph: incorrect graph type")
 	ErrDeviceIDNotFound        = errors.New("Failed to get device id")
 	ErrBrickIsMountPoint       = errors.New("Brick path is already a mount point")
@@ -31,4 +32,11 @@ var (
 	ErrPeerLocalNode           = errors.New("The peer being added is the local node")
 	ErrProcessNotFound         = errors.New("The process is not running or is inaccessible")
 	ErrProcessAlreadyRunning   = errors.New("Process is already running")
+	ErrJobNotFound             = errors.New("job not found")
+	ErrJobInterrupted          = errors.New("job was interrupted by a restart of the originating node")
+	ErrOpVersionTooLow         = errors.New("op-version is lower than the current cluster op-version")
+	ErrOpVersionUnsupported    = errors.New("op-version is not supported by all the peers")
+	ErrOpVersionChanged        = errors.New("cluster op-version was changed concurrently")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrDryRunUnsupported       = errors.New("dry run is not supported for this operation")
 )
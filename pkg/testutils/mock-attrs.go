@@ -1,5 +1,9 @@
 package testutils
 
+import (
+	"golang.org/x/sys/unix"
+)
+
 // MockRemovexattr is mock function for unix.Removexattr
 func MockRemovexattr(path string, attr string) (err error) {
 	return nil
@@ -15,6 +19,16 @@ func MockGetxattr(path string, attr string, dest []byte) (sz int, err error) {
 	return 0, nil
 }
 
+// MockAccess is mock function for unix.Access
+func MockAccess(path string, mode uint32) (err error) {
+	return nil
+}
+
+// MockStatfs is mock function for unix.Statfs
+func MockStatfs(path string, buf *unix.Statfs_t) (err error) {
+	return nil
+}
+
 // MockValidateBrickPathStats is mock function for utils.ValidateBrickPathStats
 func MockValidateBrickPathStats(brickPath string, host string, force bool) error {
 	return nil
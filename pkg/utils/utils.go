@@ -35,6 +35,10 @@ var (
 	Setxattr = unix.Setxattr
 	// Getxattr calls unix.Getxattr
 	Getxattr = unix.Getxattr
+	// Access calls unix.Access
+	Access = unix.Access
+	// Statfs calls unix.Statfs
+	Statfs = unix.Statfs
 )
 
 //PosixPathMax represents C's POSIX_PATH_MAX
@@ -230,23 +234,37 @@ func ValidateBrickPathStats(brickPath string, force bool) error {
 	return nil
 }
 
-//ValidateXattrSupport checks whether the underlying file system has extended
-//attribute support and it also sets some internal xattrs to mark the brick in
-//use
-func ValidateXattrSupport(brickPath string, volid uuid.UUID, force bool) error {
-	var err error
-	err = Setxattr(brickPath, "trusted.glusterfs.test", []byte("working"), 0)
-	if err != nil {
-		log.WithFields(log.Fields{"error": err.Error(),
-			"brickPath": brickPath,
-			"xattr":     testXattr}).Error("setxattr failed")
+// ValidateXattrSupport checks whether the underlying file system has extended
+// attribute support, that the brick is writable and, unless forced, that the
+// brick isn't already in use. The brick is left untouched, the volume ID xattr
+// is set by SetVolumeIDXattr.
+func ValidateXattrSupport(brickPath string, force bool) error {
+	// Setting xattrs needs the brick to be writable, which is checked
+	// without writing to it
+	if err := Access(brickPath, unix.W_OK); err != nil {
+		log.WithError(err).WithField(
+			"brickPath", brickPath).Error("brick path is not writable")
 		return err
 	}
-	err = Removexattr(brickPath, "trusted.glusterfs.test")
-	if err != nil {
+	var fs unix.Statfs_t
+	if err := Statfs(brickPath, &fs); err != nil {
+		log.WithError(err).WithField(
+			"brickPath", brickPath).Error("statfs failed")
+		return err
+	}
+	if fs.Flags&unix.ST_RDONLY != 0 {
+		log.WithField(
+			"brickPath", brickPath).Error("brick path is on a read-only file system")
+		return unix.EROFS
+	}
+
+	// Getting an xattr which isn't set fails with ENODATA if xattrs are
+	// supported, and with ENOTSUP otherwise
+	_, err := Getxattr(brickPath, testXattr, nil)
+	if err != nil && err != unix.ENODATA {
 		log.WithFields(log.Fields{"error": err.Error(),
 			"brickPath": brickPath,
-			"xattr":     testXattr}).Error("removexattr failed")
+			"xattr":     testXattr}).Error("getxattr failed")
 		return err
 	}
 	if !force {
@@ -257,15 +275,30 @@ func ValidateXattrSupport(brickPath string, volid uuid.UUID, force bool) error {
 		}
 	}
 
-	// FIXME: This shouldn't be part of validate
-	err = Setxattr(brickPath, volumeIDXattr, []byte(volid), 0)
+	return nil
+}
+
+// SetVolumeIDXattr marks the brick as in use by the volume with the given ID
+func SetVolumeIDXattr(brickPath string, volid uuid.UUID) error {
+	err := Setxattr(brickPath, volumeIDXattr, []byte(volid), 0)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err.Error(),
 			"brickPath": brickPath,
 			"xattr":     volumeIDXattr}).Error("setxattr failed")
 		return err
 	}
+	return nil
+}
 
+// RemoveVolumeIDXattr unmarks the brick set in use by SetVolumeIDXattr
+func RemoveVolumeIDXattr(brickPath string) error {
+	err := Removexattr(brickPath, volumeIDXattr)
+	if err != nil && err != unix.ENODATA {
+		log.WithFields(log.Fields{"error": err.Error(),
+			"brickPath": brickPath,
+			"xattr":     volumeIDXattr}).Error("removexattr failed")
+		return err
+	}
 	return nil
 }
 
@@ -87,32 +87,63 @@ func TestValidateBrickPathStats(t *testing.T) {
 }
 
 func TestValidateXattrSupport(t *testing.T) {
-	defer testutils.Patch(&Setxattr, testutils.MockSetxattr).Restore()
 	defer testutils.Patch(&Getxattr, testutils.MockGetxattr).Restore()
-	defer testutils.Patch(&Removexattr, testutils.MockRemovexattr).Restore()
-	assert.Nil(t, ValidateXattrSupport("/tmp/b1", uuid.NewRandom(), true))
+	defer testutils.Patch(&Access, testutils.MockAccess).Restore()
+	defer testutils.Patch(&Statfs, testutils.MockStatfs).Restore()
+	assert.Nil(t, ValidateXattrSupport("/tmp/b1", true))
 
-	// Some negative tests
-	var xattrErr error
+	// Validation doesn't change the brick, so it doesn't matter if
+	// setting or removing xattrs would fail
 	baderror := errors.New("Bad")
-	xattrErr = baderror
-
-	// Now check what happens when setxattr fails
 	defer testutils.Patch(&Setxattr, func(path string, attr string, data []byte, flags int) (err error) {
-		return xattrErr
+		return baderror
+	}).Restore()
+	defer testutils.Patch(&Removexattr, func(path string, attr string) (err error) {
+		return baderror
 	}).Restore()
-	assert.Equal(t, baderror, ValidateXattrSupport("/tmp/b1", uuid.NewRandom(), true))
+	assert.Nil(t, ValidateXattrSupport("/tmp/b1", true))
 
-	// Now check what happens when getxattr fails
+	// A missing xattr means xattrs are supported
 	defer testutils.Patch(&Getxattr, func(path string, attr string, dest []byte) (sz int, err error) {
-		return 0, xattrErr
+		return 0, unix.ENODATA
+	}).Restore()
+	assert.Nil(t, ValidateXattrSupport("/tmp/b1", true))
+
+	// Now check what happens when xattrs aren't supported
+	defer testutils.Patch(&Getxattr, func(path string, attr string, dest []byte) (sz int, err error) {
+		return 0, unix.ENOTSUP
+	}).Restore()
+	assert.Equal(t, unix.ENOTSUP, ValidateXattrSupport("/tmp/b1", true))
+
+	// Bricks which can't be written to are rejected
+	defer testutils.Patch(&Access, func(path string, mode uint32) (err error) {
+		return unix.EACCES
 	}).Restore()
-	assert.Equal(t, baderror, ValidateXattrSupport("/tmp/b1", uuid.NewRandom(), true))
+	assert.Equal(t, unix.EACCES, ValidateXattrSupport("/tmp/b1", true))
 
-	// Now check what happens when removexattr fails
+	defer testutils.Patch(&Access, testutils.MockAccess).Restore()
+	defer testutils.Patch(&Statfs, func(path string, buf *unix.Statfs_t) (err error) {
+		buf.Flags = unix.ST_RDONLY
+		return nil
+	}).Restore()
+	assert.Equal(t, unix.EROFS, ValidateXattrSupport("/tmp/b1", true))
+}
+
+func TestSetVolumeIDXattr(t *testing.T) {
+	defer testutils.Patch(&Setxattr, testutils.MockSetxattr).Restore()
+	defer testutils.Patch(&Removexattr, testutils.MockRemovexattr).Restore()
+	assert.Nil(t, SetVolumeIDXattr("/tmp/b1", uuid.NewRandom()))
+	assert.Nil(t, RemoveVolumeIDXattr("/tmp/b1"))
+
+	// Removing the xattr of a brick which isn't marked succeeds
 	defer testutils.Patch(&Removexattr, func(path string, attr string) (err error) {
-		return xattrErr
+		return unix.ENODATA
 	}).Restore()
-	assert.Equal(t, baderror, ValidateXattrSupport("/tmp/b1", uuid.NewRandom(), true))
+	assert.Nil(t, RemoveVolumeIDXattr("/tmp/b1"))
 
+	baderror := errors.New("Bad")
+	defer testutils.Patch(&Setxattr, func(path string, attr string, data []byte, flags int) (err error) {
+		return baderror
+	}).Restore()
+	assert.Equal(t, baderror, SetVolumeIDXattr("/tmp/b1", uuid.NewRandom()))
 }
@@ -41,6 +41,8 @@ func (p *Plugin) RestRoutes() route.Routes {
 // RegisterStepFuncs registers transaction step functions with
 // Glusterd Transaction framework
 func (p *Plugin) RegisterStepFuncs() {
+	transaction.RegisterStepFunc(txnGeorepCreateValidate, "georeplication-create.Validate")
 	transaction.RegisterStepFunc(txnGeorepCreate, "georeplication-create.Commit")
+	transaction.RegisterStepFunc(txnGeorepStartValidate, "georeplication-start.Validate")
 	transaction.RegisterStepFunc(txnGeorepStart, "georeplication-start.Commit")
 }
@@ -128,7 +128,7 @@ func georepCreateHandler(w http.ResponseWriter, r *http.Request) {
 	defer txn.Cleanup()
 
 	// Lock on Master Volume name
-	lock, unlock, err := transaction.CreateLockSteps(geoSession.MasterVol)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(geoSession.MasterVol))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -140,6 +140,11 @@ func georepCreateHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "georeplication-create.Validate",
+			Nodes:    []uuid.UUID{gdctx.MyUUID},
+			Validate: true,
+		},
 		{
 			DoFunc: "georeplication-create.Commit",
 			Nodes:  []uuid.UUID{gdctx.MyUUID},
@@ -148,6 +153,13 @@ func georepCreateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	txn.Ctx.Set("geosession", geoSession)
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, e = txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, e)
+		return
+	}
+
 	_, e = txn.Do()
 	if e != nil {
 		logger.WithFields(log.Fields{
@@ -155,7 +167,7 @@ func georepCreateHandler(w http.ResponseWriter, r *http.Request) {
 			"mastervolid": masterid,
 			"slavevolid":  slaveid,
 		}).Error("failed to create geo-replication session")
-		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, e.Error(), api.ErrCodeDefault)
+		restutils.SendHTTPTxnError(ctx, w, e)
 		return
 	}
 
@@ -208,7 +220,7 @@ func georepStartHandler(w http.ResponseWriter, r *http.Request) {
 	txn := transaction.NewTxn(ctx)
 	defer txn.Cleanup()
 
-	lock, unlock, err := transaction.CreateLockSteps(geoSession.MasterVol)
+	lock, unlock, err := transaction.CreateLockSteps(transaction.VolumeLockKey(geoSession.MasterVol))
 	if err != nil {
 		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, err.Error(), api.ErrCodeDefault)
 		return
@@ -217,6 +229,11 @@ func georepStartHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Nodes = vol.Nodes()
 	txn.Steps = []*transaction.Step{
 		lock,
+		{
+			DoFunc:   "georeplication-start.Validate",
+			Nodes:    txn.Nodes,
+			Validate: true,
+		},
 		{
 			DoFunc: "georeplication-start.Commit",
 			Nodes:  txn.Nodes,
@@ -226,6 +243,13 @@ func georepStartHandler(w http.ResponseWriter, r *http.Request) {
 	txn.Ctx.Set("mastervolid", masterid.String())
 	txn.Ctx.Set("slavevolid", slaveid.String())
 
+	if restutils.IsDryRunRequest(r) {
+		txn.DryRun = true
+		_, e = txn.Do()
+		restutils.SendHTTPDryRunResponse(ctx, w, txn, e)
+		return
+	}
+
 	_, e = txn.Do()
 	if e != nil {
 		logger.WithFields(log.Fields{
@@ -233,7 +257,7 @@ func georepStartHandler(w http.ResponseWriter, r *http.Request) {
 			"mastervolid": masterid,
 			"slavevolid":  slaveid,
 		}).Error("failed to start geo-replication session")
-		restutils.SendHTTPError(ctx, w, http.StatusInternalServerError, e.Error(), api.ErrCodeDefault)
+		restutils.SendHTTPTxnError(ctx, w, e)
 		return
 	}
 
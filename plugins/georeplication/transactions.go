@@ -1,6 +1,9 @@
 package georeplication
 
 import (
+	"errors"
+	"os"
+
 	"github.com/gluster/glusterd2/glusterd2/daemon"
 	"github.com/gluster/glusterd2/glusterd2/transaction"
 	georepapi "github.com/gluster/glusterd2/plugins/georeplication/api"
@@ -8,6 +11,25 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+func txnGeorepCreateValidate(c transaction.TxnCtx) error {
+	var sessioninfo georepapi.GeorepSession
+	if err := c.Get("geosession", &sessioninfo); err != nil {
+		return err
+	}
+
+	// The session may have been created after the request was checked,
+	// while the volume lock wasn't held
+	_, err := getSession(sessioninfo.MasterID.String(), sessioninfo.SlaveID.String())
+	if err == nil {
+		return errors.New("session already exists")
+	}
+	if _, ok := err.(*ErrGeorepSessionNotFound); !ok {
+		return err
+	}
+
+	return nil
+}
+
 func txnGeorepCreate(c transaction.TxnCtx) error {
 	var sessioninfo georepapi.GeorepSession
 	if err := c.Get("geosession", &sessioninfo); err != nil {
@@ -38,6 +60,34 @@ func startGsyncdMonitor(sess *georepapi.GeorepSession) error {
 	return nil
 }
 
+func txnGeorepStartValidate(c transaction.TxnCtx) error {
+	var masterid string
+	var slaveid string
+	if err := c.Get("mastervolid", &masterid); err != nil {
+		return err
+	}
+	if err := c.Get("slavevolid", &slaveid); err != nil {
+		return err
+	}
+
+	sessioninfo, err := getSession(masterid, slaveid)
+	if err != nil {
+		return err
+	}
+	if sessioninfo.Status == georepapi.GeorepStatusStarted {
+		return errors.New("session already started")
+	}
+
+	// gsyncd is run from its script on every node of the master volume
+	if _, err := os.Stat(gsyncdPath); err != nil {
+		c.Logger().WithError(err).WithField(
+			"path", gsyncdPath).Debug("gsyncd script is not accessible")
+		return err
+	}
+
+	return nil
+}
+
 func txnGeorepStart(c transaction.TxnCtx) error {
 	var masterid string
 	var slaveid string
@@ -12,8 +12,9 @@ var (
 	expVer = expvar.NewString("version")
 )
 
-// MaxOpVersion and APIVersion supported
+// MinOpVersion, MaxOpVersion and APIVersion supported
 const (
+	MinOpVersion = 40000
 	MaxOpVersion = 40000
 	APIVersion   = 1
 )